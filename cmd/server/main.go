@@ -3,9 +3,11 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/abushaista/lms-backend/delivery/http"
 	libMiddleWare "github.com/abushaista/lms-backend/delivery/middleware"
+	"github.com/abushaista/lms-backend/delivery/utils"
 	_ "github.com/abushaista/lms-backend/docs"
 	"github.com/abushaista/lms-backend/infrastructure/config"
 	"github.com/abushaista/lms-backend/infrastructure/database"
@@ -18,6 +20,7 @@ import (
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog"
 	echoSwagger "github.com/swaggo/echo-swagger"
 )
 
@@ -39,9 +42,30 @@ func main() {
 		log.Fatalf("failed to connect database: %v", err)
 	}
 
-	if err := db.AutoMigrate(&domain.Book{}, &domain.Category{}, &domain.User{}); err != nil {
+	if err := repository.NormalizeBookISBNs(db); err != nil {
+		log.Fatalf("failed to normalize ISBNs: %v", err)
+	}
+	// copies are backfilled only on the boot that creates their table; later a
+	// book without copies was catalogued so or had its copies withdrawn
+	backfillCopies := !db.Migrator().HasTable(&domain.BookCopy{})
+	// likewise for authors, so a book left without any is not relinked
+	backfillAuthors := !db.Migrator().HasTable("book_authors")
+	if err := db.AutoMigrate(&domain.Author{}, &domain.Tag{}, &domain.Book{}, &domain.Category{}, &domain.CategoryMerge{}, &domain.User{}, &domain.BookCopy{}, &domain.Loan{}, &domain.Reservation{}, &domain.Fine{}, &domain.FineTransaction{}, &domain.RefreshToken{}); err != nil {
 		log.Fatalf("failed to migrate: %v", err)
 	}
+	if err := repository.MigrateBookISBNIndex(db); err != nil {
+		log.Fatalf("failed to index ISBNs: %v", err)
+	}
+	if backfillCopies {
+		if err := repository.BackfillBookCopies(db); err != nil {
+			log.Fatalf("failed to backfill book copies: %v", err)
+		}
+	}
+	if backfillAuthors {
+		if err := repository.BackfillBookAuthors(db); err != nil {
+			log.Fatalf("failed to backfill book authors: %v", err)
+		}
+	}
 	rootLogger := logger.NewLogger()
 
 	e := echo.New()
@@ -49,10 +73,17 @@ func main() {
 	e.Use(libMiddleWare.CorrelationMiddleware)
 	e.Use(libMiddleWare.AttachRequestLogger(rootLogger))
 	e.Validator = validatorInfra.NewEchoValidator()
+	e.HTTPErrorHandler = http.NewHTTPErrorHandler(rootLogger)
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
 	rUser := repository.NewGormUserRepository(db)
-	ucAuth := usecase.NewAuthUseCase(rUser, cfg.JWTSecret)
+	rToken := repository.NewGormRefreshTokenRepository(db)
+	ucAuth := usecase.NewAuthUseCase(rUser, rToken, cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	if cfg.AdminUsername != "" {
+		if err := ucAuth.EnsureAdmin(cfg.AdminUsername, cfg.AdminPassword); err != nil {
+			log.Fatalf("failed to bootstrap admin: %v", err)
+		}
+	}
 
 	public := e.Group("api")
 	http.NewAuthHandler(public, ucAuth, rootLogger)
@@ -65,15 +96,70 @@ func main() {
 
 	api.Use(echojwt.WithConfig(echojwt.Config{
 		SigningKey: []byte(cfg.JWTSecret),
+		ContextKey: utils.CtxTokenKey,
+		// verifies the signature and rejects tokens of revoked sessions
+		ParseTokenFunc: func(c echo.Context, auth string) (interface{}, error) {
+			return ucAuth.ParseAccessToken(auth)
+		},
 	}))
+	api.Use(libMiddleWare.UserContext(rootLogger))
+
+	http.NewUserHandler(api, ucAuth, rootLogger)
 
 	rBook := repository.NewGormBookRepository(db)
-	ucBook := usecase.NewBookUsecase(rBook)
+	metadata := repository.NewOpenLibraryProvider(cfg.MetadataURL, nil)
+	ucBook := usecase.NewBookUsecase(rBook, newBookSearchIndex(cfg, rootLogger), metadata)
+	ucBook.OnIndexError(func(bookID int64, err error) {
+		rootLogger.Error().Err(err).Int64("book_id", bookID).Msg("search index update failed")
+	})
+	go func() {
+		if err := ucBook.Reindex(); err != nil {
+			rootLogger.Error().Err(err).Msg("reindex books")
+		}
+	}()
 	rCategory := repository.NewGormCategoryRepository(db)
-	ucCategory := usecase.NewCategoryUseCase(rCategory)
+	ucCategory := usecase.NewCategoryUseCase(rCategory, ucBook)
 
 	http.NewBookHandler(api, ucBook, rootLogger)
+
+	rCopy := repository.NewGormBookCopyRepository(db)
+	ucCopy := usecase.NewBookCopyUseCase(rCopy, ucBook)
+	http.NewBookCopyHandler(api, ucCopy, rootLogger)
 	http.NewCategoryHandler(api, ucCategory)
+	http.NewAuthorHandler(api, usecase.NewAuthorUseCase(repository.NewGormAuthorRepository(db)))
+	http.NewTagHandler(api, usecase.NewTagUseCase(repository.NewGormTagRepository(db)))
+
+	ucImport := usecase.NewBookImportUseCase(ucBook, rCategory)
+	http.NewBookImportHandler(api, ucImport, rootLogger)
+	http.NewBookExportHandler(api, usecase.NewBookExportUseCase(rBook), rootLogger)
+	ucCover := usecase.NewBookCoverUseCase(rBook, repository.NewLocalCoverStorage(cfg.CoverDir), cfg.CoverMaxBytes)
+	http.NewBookCoverHandler(public, api, ucCover, cfg.CoverMaxBytes, rootLogger)
+
+	rFine := repository.NewGormFineRepository(db)
+	ucFine := usecase.NewFineUseCase(rFine, domain.FinePolicy{
+		DailyRate:      cfg.FineDailyRate,
+		GraceDays:      cfg.FineGraceDays,
+		Cap:            cfg.FineCap,
+		BlockThreshold: cfg.FineBlockThreshold,
+	})
+	http.NewFineHandler(api, ucFine, rootLogger)
+
+	rLoan := repository.NewGormLoanRepository(db)
+	ucLoan := usecase.NewLoanUseCase(rLoan, ucFine)
+	http.NewLoanHandler(api, ucLoan, rootLogger)
+
+	rReservation := repository.NewGormReservationRepository(db)
+	ucReservation := usecase.NewReservationUseCase(rReservation, ucBook)
+	http.NewReservationHandler(api, ucReservation, rootLogger)
+
+	// release holds that were not picked up within the pickup window
+	go func() {
+		for range time.Tick(time.Minute) {
+			if err := ucReservation.ExpireReady(); err != nil {
+				rootLogger.Error().Err(err).Msg("expire reservations")
+			}
+		}
+	}()
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -86,3 +172,17 @@ func main() {
 	}
 
 }
+
+// newBookSearchIndex connects to Elasticsearch and falls back to an in-memory
+// index when it is not configured or not reachable.
+func newBookSearchIndex(cfg *config.Config, rootLogger zerolog.Logger) domain.BookSearchIndex {
+	if cfg.ElasticURL == "" {
+		return repository.NewMemoryBookIndex()
+	}
+	index := repository.NewElasticBookIndex(cfg.ElasticURL, cfg.ElasticIndex, cfg.ElasticUser, cfg.ElasticPass, nil)
+	if err := index.EnsureIndex(); err != nil {
+		rootLogger.Error().Err(err).Msg("elasticsearch unavailable, using in-memory search index")
+		return repository.NewMemoryBookIndex()
+	}
+	return index
+}
@@ -24,6 +24,8 @@ func NewAuthHandler(e *echo.Group, uc *usecase.AuthUseCase, logger zerolog.Logge
 	}
 	e.POST("/register", h.Create)
 	e.POST("/login", h.Login)
+	e.POST("/refresh", h.Refresh)
+	e.POST("/logout", h.Logout)
 }
 
 // Register godoc
@@ -33,23 +35,24 @@ func NewAuthHandler(e *echo.Group, uc *usecase.AuthUseCase, logger zerolog.Logge
 // @Produce json
 // @Param user body dto.CreateUserRequest true "User registration"
 // @Success 201 {object} map[string]string
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} utils.Problem
+// @Failure 409 {object} utils.Problem
 // @Router /api/register [post]
 func (h AuthHandler) Create(c echo.Context) error {
 	logger := utils.WithRequestLogger(h.rootLogger, c)
 	var req dto.CreateUserRequest
 	if err := c.Bind(&req); err != nil {
 		logger.Warn().Err(err).Msg("bind user")
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request payload"})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
 	}
 	if err := c.Validate(&req); err != nil {
 		logger.Warn().Err(err).Msg("invalid payload")
-		return c.JSON(http.StatusBadRequest, utils.FormatValidationErrors(err))
+		return err
 	}
 	_, err := h.uc.Create(req)
 	if err != nil {
-		logger.Warn().Err(err).Msg("500 internal server error")
-		return c.JSON(http.StatusUnauthorized, echo.Map{"error": err.Error()})
+		logger.Warn().Err(err).Msg("register failed")
+		return err
 	}
 	return c.JSON(http.StatusCreated, echo.Map{"message": "user registered"})
 }
@@ -60,20 +63,76 @@ func (h AuthHandler) Create(c echo.Context) error {
 // @Accept json
 // @Produce json
 // @Param user body dto.LoginRequest true "User login"
-// @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
+// @Success 200 {object} domain.TokenPair
+// @Failure 400 {object} utils.Problem
+// @Failure 401 {object} utils.Problem
 // @Router /api/login [post]
 func (h AuthHandler) Login(c echo.Context) error {
 	var req dto.LoginRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request payload"})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	pair, err := h.uc.Login(req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, pair)
+}
+
+// Refresh godoc
+// @Summary Refresh an access token
+// @Description Exchange a refresh token for a new token pair. Each refresh token is single use; reusing one revokes the whole session.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body dto.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} domain.TokenPair
+// @Failure 400 {object} utils.Problem
+// @Failure 401 {object} utils.Problem
+// @Router /api/refresh [post]
+func (h AuthHandler) Refresh(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	var req dto.RefreshTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
 	}
 	if err := c.Validate(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, utils.FormatValidationErrors(err))
+		return err
 	}
-	token, err := h.uc.Login(req)
+	pair, err := h.uc.Refresh(req)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, echo.Map{"error": err.Error()})
+		logger.Warn().Err(err).Msg("refresh failed")
+		return err
+	}
+	return c.JSON(http.StatusOK, pair)
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the session of a refresh token together with its access tokens
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body dto.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} utils.Problem
+// @Failure 401 {object} utils.Problem
+// @Router /api/logout [post]
+func (h AuthHandler) Logout(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	var req dto.RefreshTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	if err := h.uc.Logout(req); err != nil {
+		logger.Warn().Err(err).Msg("logout failed")
+		return err
 	}
-	return c.JSON(http.StatusOK, echo.Map{"token": token})
+	return c.JSON(http.StatusOK, echo.Map{"message": "logged out"})
 }
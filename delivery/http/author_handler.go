@@ -0,0 +1,166 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	libMiddleWare "github.com/abushaista/lms-backend/delivery/middleware"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/abushaista/lms-backend/internal/usecase"
+	"github.com/labstack/echo/v4"
+)
+
+type AuthorHandler struct {
+	uc *usecase.AuthorUseCase
+}
+
+func NewAuthorHandler(e *echo.Group, uc *usecase.AuthorUseCase) {
+	h := &AuthorHandler{uc: uc}
+	e.POST("/authors", h.CreateAuthor, libMiddleWare.RequireStaff)
+	e.PUT("/authors/:id", h.UpdateAuthor, libMiddleWare.RequireStaff)
+	e.DELETE("/authors/:id", h.Delete, libMiddleWare.RequireStaff)
+	e.GET("/authors", h.GetByFilterAll)
+	e.GET("/authors/:id", h.GetByID)
+}
+
+// CreateAuthor godoc
+// @Summary      Create an author
+// @Description  Add an author that books can be linked to
+// @Tags         authors
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.AuthorRequest  true  "Author payload"
+// @Success      201   {object}  domain.Author
+// @Failure      400   {object}  utils.Problem
+// @Failure      403   {object}  utils.Problem
+// @Failure      409   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /authors [post]
+func (h *AuthorHandler) CreateAuthor(c echo.Context) error {
+	var req dto.AuthorRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	req.ID = 0
+	author, err := h.uc.Save(req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, author)
+}
+
+// UpdateAuthor godoc
+// @Summary      Rename an author
+// @Description  Update the name of an author. Bylines of books already catalogued are left as they are.
+// @Tags         authors
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                true  "Author ID"
+// @Param        body  body      dto.AuthorRequest  true  "Author payload"
+// @Success      200   {object}  domain.Author
+// @Failure      400   {object}  utils.Problem
+// @Failure      403   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      409   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /authors/{id} [put]
+func (h *AuthorHandler) UpdateAuthor(c echo.Context) error {
+	var req dto.AuthorRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	req.ID = uint(id)
+	author, err := h.uc.Save(req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, author)
+}
+
+// Delete godoc
+// @Summary      Delete an author
+// @Description  Remove an author no book credits any more
+// @Tags         authors
+// @Produce      json
+// @Param        id   path      int  true  "Author ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  utils.Problem
+// @Failure      403  {object}  utils.Problem
+// @Failure      404  {object}  utils.Problem
+// @Failure      409  {object}  utils.Problem
+// @Failure      500  {object}  utils.Problem
+// @Router       /authors/{id} [delete]
+func (h *AuthorHandler) Delete(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	if err := h.uc.Delete(uint(id)); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Author deleted"})
+}
+
+// GetByFilterAll godoc
+// @Summary      Get authors with pagination
+// @Description  Retrieve authors ordered by name, optionally filtered by a part of their name
+// @Tags         authors
+// @Produce      json
+// @Param        page    query     int     false  "Page number"     default(1)
+// @Param        limit   query     int     false  "Items per page"  default(10)
+// @Param        filter  query     string  false  "Filter string"
+// @Success      200     {object}  map[string]interface{}
+// @Failure      500     {object}  utils.Problem
+// @Router       /authors [get]
+func (h *AuthorHandler) GetByFilterAll(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	authors, total, err := h.uc.GetByFilterAll(page, limit, c.QueryParam("filter"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  authors,
+		"total": total,
+		"page":  page,
+	})
+}
+
+// GetByID godoc
+// @Summary      Get an author by ID
+// @Description  Retrieve a single author by its ID
+// @Tags         authors
+// @Produce      json
+// @Param        id   path      int  true  "Author ID"
+// @Success      200  {object}  domain.Author
+// @Failure      400  {object}  utils.Problem
+// @Failure      404  {object}  utils.Problem
+// @Failure      500  {object}  utils.Problem
+// @Router       /authors/{id} [get]
+func (h *AuthorHandler) GetByID(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	author, err := h.uc.GetByID(uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, author)
+}
@@ -0,0 +1,197 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	libMiddleWare "github.com/abushaista/lms-backend/delivery/middleware"
+	"github.com/abushaista/lms-backend/delivery/utils"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/abushaista/lms-backend/internal/usecase"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type BookCopyHandler struct {
+	uc         *usecase.BookCopyUseCase
+	rootLogger zerolog.Logger
+}
+
+func NewBookCopyHandler(e *echo.Group, uc *usecase.BookCopyUseCase, logger zerolog.Logger) {
+	h := &BookCopyHandler{
+		uc:         uc,
+		rootLogger: logger,
+	}
+	e.GET("/books/:id/copies", h.GetByBook)
+	e.POST("/books/:id/copies", h.CreateCopy, libMiddleWare.RequireStaff)
+	e.GET("/books/:id/copies/:copyId", h.GetByID)
+	e.PUT("/books/:id/copies/:copyId", h.UpdateCopy, libMiddleWare.RequireStaff)
+	e.DELETE("/books/:id/copies/:copyId", h.Delete, libMiddleWare.RequireStaff)
+}
+
+// GetByBook godoc
+// @Summary      List copies of a book
+// @Description  Retrieve every physical copy of a book
+// @Tags         copies
+// @Produce      json
+// @Param        id   path      int  true  "Book ID"
+// @Success      200  {array}   domain.BookCopy
+// @Failure      400  {object}  utils.Problem
+// @Failure      404  {object}  utils.Problem
+// @Failure      500  {object}  utils.Problem
+// @Router       /books/{id}/copies [get]
+func (h *BookCopyHandler) GetByBook(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	bookID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	copies, err := h.uc.GetByBook(int64(bookID))
+	if err != nil {
+		logger.Warn().Err(err).Msg("list copies")
+		return err
+	}
+	return c.JSON(http.StatusOK, copies)
+}
+
+// CreateCopy godoc
+// @Summary      Add a copy to a book
+// @Description  Register a new physical copy of a book
+// @Tags         copies
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                  true  "Book ID"
+// @Param        body  body      dto.BookCopyRequest  true  "Copy payload"
+// @Success      201   {object}  domain.BookCopy
+// @Failure      400   {object}  utils.Problem
+// @Failure      403   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      409   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /books/{id}/copies [post]
+func (h *BookCopyHandler) CreateCopy(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	bookID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	var req dto.BookCopyRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Warn().Err(err).Msg("bind copy")
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	req.ID = 0
+	req.BookID = int64(bookID)
+	bookCopy, err := h.uc.Save(req)
+	if err != nil {
+		logger.Warn().Err(err).Msg("create copy")
+		return err
+	}
+	return c.JSON(http.StatusCreated, bookCopy)
+}
+
+// GetByID godoc
+// @Summary      Get a copy of a book
+// @Description  Retrieve a single physical copy by its ID
+// @Tags         copies
+// @Produce      json
+// @Param        id      path      int  true  "Book ID"
+// @Param        copyId  path      int  true  "Copy ID"
+// @Success      200     {object}  domain.BookCopy
+// @Failure      400     {object}  utils.Problem
+// @Failure      404     {object}  utils.Problem
+// @Failure      500     {object}  utils.Problem
+// @Router       /books/{id}/copies/{copyId} [get]
+func (h *BookCopyHandler) GetByID(c echo.Context) error {
+	bookID, copyID, err := copyParams(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	bookCopy, err := h.uc.GetByID(bookID, copyID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, bookCopy)
+}
+
+// UpdateCopy godoc
+// @Summary      Update a copy of a book
+// @Description  Update barcode, shelf location, condition or status of a copy. The status of a copy on loan or on hold cannot be changed.
+// @Tags         copies
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                  true  "Book ID"
+// @Param        copyId  path      int                  true  "Copy ID"
+// @Param        body    body      dto.BookCopyRequest  true  "Copy payload"
+// @Success      200     {object}  domain.BookCopy
+// @Failure      400     {object}  utils.Problem
+// @Failure      403     {object}  utils.Problem
+// @Failure      404     {object}  utils.Problem
+// @Failure      409     {object}  utils.Problem
+// @Failure      500     {object}  utils.Problem
+// @Router       /books/{id}/copies/{copyId} [put]
+func (h *BookCopyHandler) UpdateCopy(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	bookID, copyID, err := copyParams(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	var req dto.BookCopyRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Warn().Err(err).Msg("bind copy")
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	req.ID = copyID
+	req.BookID = bookID
+	bookCopy, err := h.uc.Save(req)
+	if err != nil {
+		logger.Warn().Err(err).Msg("update copy")
+		return err
+	}
+	return c.JSON(http.StatusOK, bookCopy)
+}
+
+// Delete godoc
+// @Summary      Delete a copy of a book
+// @Description  Remove a physical copy that is not currently on loan or on hold
+// @Tags         copies
+// @Produce      json
+// @Param        id      path      int  true  "Book ID"
+// @Param        copyId  path      int  true  "Copy ID"
+// @Success      200     {object}  map[string]string
+// @Failure      400     {object}  utils.Problem
+// @Failure      403     {object}  utils.Problem
+// @Failure      404     {object}  utils.Problem
+// @Failure      409     {object}  utils.Problem
+// @Failure      500     {object}  utils.Problem
+// @Router       /books/{id}/copies/{copyId} [delete]
+func (h *BookCopyHandler) Delete(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	bookID, copyID, err := copyParams(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	if err := h.uc.Delete(bookID, copyID); err != nil {
+		logger.Warn().Err(err).Msg("delete copy")
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "copy deleted"})
+}
+
+func copyParams(c echo.Context) (int64, int64, error) {
+	bookID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	copyID, err := strconv.Atoi(c.Param("copyId"))
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(bookID), int64(copyID), nil
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	libMiddleWare "github.com/abushaista/lms-backend/delivery/middleware"
+	"github.com/abushaista/lms-backend/delivery/utils"
+	"github.com/abushaista/lms-backend/internal/usecase"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog"
+)
+
+// coverCacheControl lets clients keep a cover for a day; a new upload gets a
+// new ETag and a new cover_image URL.
+const coverCacheControl = "private, max-age=86400"
+
+type BookCoverHandler struct {
+	uc         *usecase.BookCoverUseCase
+	rootLogger zerolog.Logger
+}
+
+// NewBookCoverHandler serves covers on public, since clients load them as
+// plain images without a token, and takes uploads on e.
+func NewBookCoverHandler(public, e *echo.Group, uc *usecase.BookCoverUseCase, maxBytes int64, logger zerolog.Logger) {
+	h := &BookCoverHandler{
+		uc:         uc,
+		rootLogger: logger,
+	}
+	// leaves room for the multipart envelope around the image
+	limit := strconv.FormatInt(maxBytes/1024+64, 10) + "K"
+	e.POST("/books/:id/cover", h.Upload, libMiddleWare.RequireStaff, middleware.BodyLimit(limit))
+	public.GET("/books/:id/cover", h.Get)
+}
+
+// Upload godoc
+// @Summary      Upload a book cover
+// @Description  Upload a JPEG, PNG or GIF cover image. The type is detected from the content; a thumbnail is generated and the book's cover_image points at the stored image. Librarians and admins only.
+// @Tags         books
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id    path      int   true  "Book ID"
+// @Param        file  formData  file  true  "Cover image"
+// @Success      200   {object}  domain.Book
+// @Failure      400   {object}  utils.Problem
+// @Failure      403   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      413   {object}  utils.Problem
+// @Failure      415   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /books/{id}/cover [post]
+func (h *BookCoverHandler) Upload(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "missing file")
+	}
+	src, err := file.Open()
+	if err != nil {
+		logger.Error().Err(err).Msg("open upload")
+		return err
+	}
+	defer src.Close()
+
+	book, err := h.uc.Upload(int64(id), src)
+	if err != nil {
+		logger.Warn().Err(err).Int("book_id", id).Msg("cover upload failed")
+		return err
+	}
+	logger.Info().Int64("book_id", book.ID).Str("cover", book.CoverImageURL).Msg("cover uploaded")
+	return c.JSON(http.StatusOK, book)
+}
+
+// Get godoc
+// @Summary      Get a book cover
+// @Description  Serve the uploaded cover image, or its thumbnail, with ETag, Last-Modified and Cache-Control headers.
+// @Tags         books
+// @Produce      image/jpeg
+// @Produce      image/png
+// @Produce      image/gif
+// @Param        id    path      int     true   "Book ID"
+// @Param        size  query     string  false  "thumb for the thumbnail"
+// @Success      200   {file}    file
+// @Success      304   {string}  string  "Not Modified"
+// @Failure      400   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /books/{id}/cover [get]
+func (h *BookCoverHandler) Get(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	obj, etag, err := h.uc.Open(int64(id), c.QueryParam("size") == "thumb")
+	if err != nil {
+		logger.Warn().Err(err).Int("book_id", id).Msg("cover not served")
+		return err
+	}
+	defer obj.Content.Close()
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, obj.ContentType)
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", coverCacheControl)
+	header.Set("X-Content-Type-Options", "nosniff")
+	// handles If-None-Match, If-Modified-Since and Range
+	http.ServeContent(c.Response(), c.Request(), "", obj.ModTime, obj.Content)
+	return nil
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/abushaista/lms-backend/delivery/utils"
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/usecase"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type BookExportHandler struct {
+	uc         *usecase.BookExportUseCase
+	rootLogger zerolog.Logger
+}
+
+func NewBookExportHandler(e *echo.Group, uc *usecase.BookExportUseCase, logger zerolog.Logger) {
+	h := &BookExportHandler{
+		uc:         uc,
+		rootLogger: logger,
+	}
+	e.GET("/books/export", h.Export)
+}
+
+// Export godoc
+// @Summary      Export the catalogue
+// @Description  Stream the books matching the list filters as CSV (same columns as the import), NDJSON or MARCXML (MARC 21 slim)
+// @Tags         books
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Produce      application/marcxml+xml
+// @Param        format                 query     string  true   "csv, ndjson or marcxml"
+// @Param        title                  query     string  false  "Filter by book title"
+// @Param        author                 query     string  false  "Filter by author name"
+// @Param        summary                query     string  false  "Filter by book summary"
+// @Param        isbn                   query     string  false  "Filter by ISBN-10 or ISBN-13"
+// @Param        category               query     []int   false  "Filter by category IDs"  collectionFormat(csv)
+// @Param        include_subcategories  query     bool    false  "Also match books in categories below category"
+// @Param        year                   query     int     false  "Filter by publication year"
+// @Param        year_from              query     int     false  "Published in or after this year"
+// @Param        year_to                query     int     false  "Published in or before this year"
+// @Param        available              query     bool    false  "Filter by having a copy on the shelf"
+// @Param        created_from           query     string  false  "Created on or after this date or RFC 3339 time"
+// @Param        created_to             query     string  false  "Created on or before this date or RFC 3339 time"
+// @Param        updated_from           query     string  false  "Updated on or after this date or RFC 3339 time"
+// @Param        updated_to             query     string  false  "Updated on or before this date or RFC 3339 time"
+// @Param        author_id              query     int     false  "Filter by linked author ID"
+// @Param        tag_id                 query     int     false  "Filter by tag ID"
+// @Success      200                    {file}    file
+// @Failure      400                    {object}  utils.Problem
+// @Router       /books/export [get]
+func (h *BookExportHandler) Export(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	format := c.QueryParam("format")
+	contentType, ok := usecase.ExportContentType(format)
+	if !ok {
+		return domain.ErrExportFormat
+	}
+	filter, err := bookFilterParams(c)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, contentType)
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="books.`+format+`"`)
+	res.WriteHeader(http.StatusOK)
+
+	// the status is already sent, so a failure can only cut the stream short
+	if err := h.uc.Export(res, format, filter); err != nil {
+		logger.Error().Err(err).Str("format", format).Msg("export interrupted")
+	}
+	return nil
+}
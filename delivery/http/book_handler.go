@@ -3,7 +3,10 @@ package http
 import (
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	libMiddleWare "github.com/abushaista/lms-backend/delivery/middleware"
 	"github.com/abushaista/lms-backend/delivery/utils"
 	"github.com/abushaista/lms-backend/internal/domain"
 	"github.com/abushaista/lms-backend/internal/dto"
@@ -25,177 +28,419 @@ func NewBookHandler(e *echo.Group, uc *usecase.BookUseCase, logger zerolog.Logge
 		validate:   validator.New(),
 		rootLogger: logger,
 	}
-	e.POST("/books", h.CreateBook)
+	e.POST("/books", h.CreateBook, libMiddleWare.RequireStaff)
+	e.GET("/books/search", h.Search)
+	e.GET("/books/lookup", h.Lookup, libMiddleWare.RequireStaff)
 	e.GET("/books/:id", h.GetByID)
 	e.GET("/books", h.GetByFilterAll)
-	e.DELETE("books/:id", h.Delete)
-	e.PUT("/books/:id", h.UpdateBook)
+	e.DELETE("/books/:id", h.Delete, libMiddleWare.RequireStaff)
+	e.PUT("/books/:id", h.UpdateBook, libMiddleWare.RequireStaff)
+	e.PATCH("/books/:id", h.PatchBook, libMiddleWare.RequireStaff)
 }
 
 // CreateBook godoc
 // @Summary      Create a new book
-// @Description  Add a new book to the library
+// @Description  Add a new book to the library. The ISBN may be an ISBN-10 or ISBN-13, with or without hyphens, and is stored as ISBN-13. With enrich=true the fields left empty are filled from the ISBN metadata service.
 // @Tags         books
 // @Accept       json
 // @Produce      json
-// @Param        body  body      dto.CreateBookRequest  true  "Create Book Payload"
-// @Success      201   {object}  domain.Book
-// @Failure      400   {object}  map[string]interface{}
-// @Failure      500   {object}  map[string]string
+// @Param        body    body      dto.CreateBookRequest  true   "Create Book Payload"
+// @Param        enrich  query     bool                   false  "Fill missing fields from the ISBN metadata service"
+// @Success      201     {object}  domain.Book
+// @Failure      400     {object}  utils.Problem
+// @Failure      403     {object}  utils.Problem
+// @Failure      409     {object}  utils.Problem
+// @Failure      500     {object}  utils.Problem
+// @Failure      502     {object}  utils.Problem
 // @Router       /books [post]
 func (h *BookHandler) CreateBook(c echo.Context) error {
 	logger := utils.WithRequestLogger(h.rootLogger, c)
 	var req dto.CreateBookRequest
 	if err := c.Bind(&req); err != nil {
 		logger.Warn().Err(err).Msg("bind books")
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request payload"})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if enrich, _ := strconv.ParseBool(c.QueryParam("enrich")); enrich {
+		if err := h.uc.Enrich(&req); err != nil {
+			logger.Warn().Err(err).Str("isbn", req.ISBN).Msg("enrich book failed")
+			return err
+		}
 	}
 
 	if err := c.Validate(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, utils.FormatValidationErrors(err))
+		return err
 	}
 	book, err := h.uc.CreateBook(req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+		logger.Warn().Err(err).Msg("create book failed")
+		return err
 	}
 
+	c.Response().Header().Set("ETag", bookETag(book))
 	return c.JSON(http.StatusCreated, book)
 }
 
 // GetByFilterAll godoc
 // @Summary      Get books by filters with pagination
-// @Description  Retrieve list of books filtered by title, author, summary, category, and year with pagination support
+// @Description  Retrieve list of books filtered by title, author, summary, category, and year with pagination support. Each book carries its total and available copy counts. Pages are selected by number or, for stable paging over a large catalogue, by the next_cursor and prev_cursor of the previous response; the Link header links the first, next and previous pages.
 // @Tags         books
 // @Accept       json
 // @Produce      json
-// @Param        page      query     int     false  "Page number"      default(1)
-// @Param        limit     query     int     false  "Items per page"   default(10)
-// @Param        title     query     string  false  "Filter by book title"
-// @Param        author    query     string  false  "Filter by author name"
-// @Param        summary   query     string  false  "Filter by book summary"
-// @Param        category  query     int     false  "Filter by category ID"
-// @Param        year      query     int     false  "Filter by publication year"
-// @Success      200       {object}  map[string]interface{}
-// @Failure      500       {object}  map[string]string
+// @Param        page                   query     int     false  "Page number"                  default(1)
+// @Param        limit                  query     int     false  "Items per page, at most 100"  default(10)
+// @Param        sort                   query     string  false  "Sort fields among id, title, author, year and isbn, - for descending, as in title,-year"
+// @Param        cursor                 query     string  false  "next_cursor or prev_cursor of a previous page"
+// @Param        title                  query     string  false  "Filter by book title"
+// @Param        author                 query     string  false  "Filter by author name"
+// @Param        summary                query     string  false  "Filter by book summary"
+// @Param        isbn                   query     string  false  "Filter by ISBN-10 or ISBN-13"
+// @Param        category               query     []int   false  "Filter by category IDs"  collectionFormat(csv)
+// @Param        include_subcategories  query     bool    false  "Also match books in categories below category"
+// @Param        year                   query     int     false  "Filter by publication year"
+// @Param        year_from              query     int     false  "Published in or after this year"
+// @Param        year_to                query     int     false  "Published in or before this year"
+// @Param        available              query     bool    false  "Filter by having a copy on the shelf"
+// @Param        created_from           query     string  false  "Created on or after this date or RFC 3339 time"
+// @Param        created_to             query     string  false  "Created on or before this date or RFC 3339 time"
+// @Param        updated_from           query     string  false  "Updated on or after this date or RFC 3339 time"
+// @Param        updated_to             query     string  false  "Updated on or before this date or RFC 3339 time"
+// @Param        author_id              query     int     false  "Filter by linked author ID"
+// @Param        tag_id                 query     int     false  "Filter by tag ID"
+// @Success      200                    {object}  map[string]interface{}
+// @Header       200                    {string}  Link  "Links to the first, next and previous pages"
+// @Failure      400                    {object}  utils.Problem
+// @Failure      500                    {object}  utils.Problem
 // @Router       /books [get]
 func (h *BookHandler) GetByFilterAll(c echo.Context) error {
-	logger := utils.WithRequestLogger(h.rootLogger, c)
-	page, _ := strconv.Atoi(c.QueryParam("page"))
-	limit, _ := strconv.Atoi(c.QueryParam("limit"))
-
-	if page <= 0 {
-		page = 1
-	}
-	if limit <= 0 {
-		limit = 10
+	q, err := pageQuery(c, domain.BookSortFields)
+	if err != nil {
+		return err
 	}
-	filter := domain.BookFilter{
-		Title:   c.QueryParam("title"),
-		Author:  c.QueryParam("author"),
-		Summary: c.QueryParam("summary"),
+	filter, err := bookFilterParams(c)
+	if err != nil {
+		return err
 	}
 
-	category, err := strconv.Atoi(c.QueryParam("category"))
-	if err == nil {
-		filter.Category = category
+	books, err := h.uc.GetByFilterAll(filter, q)
+	if err != nil {
+		return err
 	}
+	return writePage(c, q, books)
+}
 
-	year, err := strconv.Atoi(c.QueryParam("year"))
-	if err == nil {
-		filter.Year = year
+// Search godoc
+// @Summary      Full-text book search
+// @Description  Search title, author, ISBN and summary with relevance ranking and typo tolerance. Returns highlighted fragments and facets by category and year; the category and year filters do not change the facet counts.
+// @Tags         books
+// @Produce      json
+// @Param        q         query     string  false  "Search text"
+// @Param        category  query     int     false  "Filter by category ID"
+// @Param        year      query     int     false  "Filter by publication year"
+// @Param        page      query     int     false  "Page number"     default(1)
+// @Param        limit     query     int     false  "Items per page"  default(10)
+// @Success      200       {object}  domain.BookSearchResult
+// @Failure      500       {object}  utils.Problem
+// @Router       /books/search [get]
+func (h *BookHandler) Search(c echo.Context) error {
+	page, limit := pageParams(c)
+	q := domain.BookSearchQuery{
+		Query: c.QueryParam("q"),
+		Page:  page,
+		Limit: limit,
+	}
+	if category, err := strconv.Atoi(c.QueryParam("category")); err == nil {
+		q.Category = uint(category)
+	}
+	if year, err := strconv.Atoi(c.QueryParam("year")); err == nil {
+		q.Year = year
 	}
 
-	books, total, err := h.uc.GetByFilterAll(page, limit, filter)
+	result, err := h.uc.Search(q)
 	if err != nil {
-		logger.Error().Err(err).Msg("500 internal server error")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"data":  books,
-		"total": total,
-		"page":  page,
+		"data":   result.Hits,
+		"total":  result.Total,
+		"page":   page,
+		"facets": result.Facets,
 	})
 }
 
+// Lookup godoc
+// @Summary      Look up an ISBN
+// @Description  Fetch title, author, year, summary and cover of an ISBN from the metadata service. Librarians and admins only.
+// @Tags         books
+// @Produce      json
+// @Param        isbn  query     string  true  "ISBN-10 or ISBN-13"
+// @Success      200   {object}  domain.BookMetadata
+// @Failure      400   {object}  utils.Problem
+// @Failure      403   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      502   {object}  utils.Problem
+// @Router       /books/lookup [get]
+func (h *BookHandler) Lookup(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	meta, err := h.uc.Lookup(c.QueryParam("isbn"))
+	if err != nil {
+		logger.Warn().Err(err).Msg("isbn lookup failed")
+		return err
+	}
+	return c.JSON(http.StatusOK, meta)
+}
+
 // GetByID godoc
 // @Summary      Get a book by ID
-// @Description  Retrieve a single book by its ID
+// @Description  Retrieve a single book by its ID. The ETag response header is required as If-Match to update or delete the book; sending it as If-None-Match answers 304 while the book is unchanged.
 // @Tags         books
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "Book ID"
-// @Success      200  {object}  domain.Book
-// @Failure      500  {object}  map[string]string
+// @Param        id             path      int     true   "Book ID"
+// @Param        If-None-Match  header    string  false  "ETag of the copy held by the client"
+// @Success      200            {object}  domain.Book
+// @Success      304            {string}  string  "Not Modified"
+// @Failure      400            {object}  utils.Problem
+// @Failure      404            {object}  utils.Problem
+// @Failure      500            {object}  utils.Problem
 // @Router       /books/{id} [get]
 func (h *BookHandler) GetByID(c echo.Context) error {
-	logger := utils.WithRequestLogger(h.rootLogger, c)
-	id, _ := strconv.Atoi(c.Param("id"))
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
 
 	data, err := h.uc.GetByID(int64(id))
 	if err != nil {
-		logger.Error().Err(err).Msg("500 internal server error")
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+		return err
+	}
+	if notModified(c, bookETag(data)) {
+		return c.NoContent(http.StatusNotModified)
 	}
 	return c.JSON(http.StatusOK, data)
 }
 
 // Delete godoc
 // @Summary      Delete a book by ID
-// @Description  Delete a book from the system by its ID
+// @Description  Delete a book from the system by its ID. If-Match must carry the ETag of the book, or * to delete any version. A book with open loans or holds is not deleted and 409 is returned.
 // @Tags         books
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "Book ID"
-// @Success      200  {object}  map[string]string
-// @Failure      400  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Param        id        path      int     true  "Book ID"
+// @Param        If-Match  header    string  true  "ETag of the book"
+// @Success      200       {object}  map[string]string
+// @Failure      400       {object}  utils.Problem
+// @Failure      403       {object}  utils.Problem
+// @Failure      404       {object}  utils.Problem
+// @Failure      409       {object}  utils.Problem
+// @Failure      412       {object}  utils.Problem
+// @Failure      428       {object}  utils.Problem
+// @Failure      500       {object}  utils.Problem
 // @Router       /books/{id} [delete]
 func (h *BookHandler) Delete(c echo.Context) error {
 	logger := utils.WithRequestLogger(h.rootLogger, c)
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		logger.Error().Err(err).Msg("invalid id")
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
 	}
-	if err := h.uc.DeleteBook(int64(id)); err != nil {
-		logger.Error().Err(err).Msg("500 internal server error")
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	if err := h.uc.DeleteBook(int64(id), version); err != nil {
+		return err
 	}
 	return c.JSON(http.StatusOK, map[string]string{"message": "book deleted"})
 }
 
 // UpdateBook godoc
 // @Summary      Update a book by ID
-// @Description  Update the details of an existing book by its ID
+// @Description  Update the details of an existing book by its ID. If-Match must carry the ETag the book was read with; 412 means someone else changed it in the meantime.
 // @Tags         books
 // @Accept       json
 // @Produce      json
-// @Param        id    path      int                 true  "Book ID"
-// @Param        body  body      dto.UpdateBookRequest  true  "Update Book Payload"
-// @Success      200   {object}  domain.Book
-// @Failure      400   {object}  map[string]interface{}
-// @Failure      500   {object}  map[string]string
+// @Param        id        path      int                    true  "Book ID"
+// @Param        If-Match  header    string                 true  "ETag of the book"
+// @Param        body      body      dto.UpdateBookRequest  true  "Update Book Payload"
+// @Success      200       {object}  domain.Book
+// @Failure      400       {object}  utils.Problem
+// @Failure      403       {object}  utils.Problem
+// @Failure      404       {object}  utils.Problem
+// @Failure      409       {object}  utils.Problem
+// @Failure      412       {object}  utils.Problem
+// @Failure      428       {object}  utils.Problem
+// @Failure      500       {object}  utils.Problem
 // @Router       /books/{id} [put]
 func (h *BookHandler) UpdateBook(c echo.Context) error {
 	logger := utils.WithRequestLogger(h.rootLogger, c)
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
 	}
 	var req dto.UpdateBookRequest
 	if err := c.Bind(&req); err != nil {
 		logger.Warn().Err(err).Msg("invalid payload")
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request payload"})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
 	}
 	req.ID = int64(id)
+	if req.Version, err = ifMatchVersion(c); err != nil {
+		return err
+	}
 	if err := c.Validate(&req); err != nil {
 		logger.Warn().Err(err).Msg("invalid payload")
-		return c.JSON(http.StatusBadRequest, utils.FormatValidationErrors(err))
+		return err
 	}
 	book, err := h.uc.UpdateBook(req)
 	if err != nil {
-		logger.Warn().Err(err).Msg("500 internal server error")
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+		logger.Warn().Err(err).Msg("update book failed")
+		return err
+	}
+	c.Response().Header().Set("ETag", bookETag(book))
+	return c.JSON(http.StatusOK, book)
+}
+
+// PatchBook godoc
+// @Summary      Partially update a book by ID
+// @Description  Change some fields of a book with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902), told apart by the Content-Type. The patch applies to the book in the shape of the PUT payload, author_ids and tag_ids included, and the result is validated like a PUT. If-Match must carry the ETag the book was read with. A JSON Patch whose paths are missing or whose test operations fail is answered with 409.
+// @Tags         books
+// @Accept       application/merge-patch+json,application/json-patch+json
+// @Produce      json
+// @Param        id        path      int                    true  "Book ID"
+// @Param        If-Match  header    string                 true  "ETag of the book"
+// @Param        body      body      dto.UpdateBookRequest  true  "Merge patch of the fields to change, or JSON Patch operations"
+// @Success      200       {object}  domain.Book
+// @Failure      400       {object}  utils.Problem
+// @Failure      403       {object}  utils.Problem
+// @Failure      404       {object}  utils.Problem
+// @Failure      409       {object}  utils.Problem
+// @Failure      412       {object}  utils.Problem
+// @Failure      415       {object}  utils.Problem
+// @Failure      428       {object}  utils.Problem
+// @Failure      500       {object}  utils.Problem
+// @Router       /books/{id} [patch]
+func (h *BookHandler) PatchBook(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	patch, err := bindPatch(c)
+	if err != nil {
+		return err
 	}
+	book, err := h.uc.PatchBook(int64(id), version, func(req *dto.UpdateBookRequest) error {
+		return patch(req)
+	})
+	if err != nil {
+		logger.Warn().Err(err).Msg("patch book failed")
+		return err
+	}
+	c.Response().Header().Set("ETag", bookETag(book))
 	return c.JSON(http.StatusOK, book)
 }
+
+// bookFilterParams reads the domain.BookFilter query parameters. category
+// may be repeated or list several ids separated by commas; the date ranges
+// take a date, covering the whole day, or an RFC 3339 time.
+func bookFilterParams(c echo.Context) (domain.BookFilter, error) {
+	filter := domain.BookFilter{
+		Title:   c.QueryParam("title"),
+		Author:  c.QueryParam("author"),
+		Summary: c.QueryParam("summary"),
+	}
+	if isbn := c.QueryParam("isbn"); isbn != "" {
+		normalized, err := domain.NormalizeISBN(isbn)
+		if err != nil {
+			return filter, err
+		}
+		filter.ISBN = normalized
+	}
+
+	for _, param := range c.QueryParams()["category"] {
+		for _, v := range strings.Split(param, ",") {
+			category, err := strconv.ParseUint(strings.TrimSpace(v), 10, 32)
+			if err != nil {
+				return filter, echo.NewHTTPError(http.StatusBadRequest, "invalid category")
+			}
+			filter.Categories = append(filter.Categories, uint(category))
+		}
+	}
+	filter.IncludeSubcategories, _ = strconv.ParseBool(c.QueryParam("include_subcategories"))
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{{"year", &filter.Year}, {"year_from", &filter.YearFrom}, {"year_to", &filter.YearTo}}
+	for _, p := range ints {
+		if v := c.QueryParam(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return filter, echo.NewHTTPError(http.StatusBadRequest, "invalid "+p.name)
+			}
+			*p.dst = n
+		}
+	}
+
+	if v := c.QueryParam("available"); v != "" {
+		available, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, echo.NewHTTPError(http.StatusBadRequest, "invalid available")
+		}
+		filter.Available = &available
+	}
+
+	times := []struct {
+		name string
+		dst  *time.Time
+		end  bool
+	}{
+		{"created_from", &filter.CreatedFrom, false},
+		{"created_to", &filter.CreatedTo, true},
+		{"updated_from", &filter.UpdatedFrom, false},
+		{"updated_to", &filter.UpdatedTo, true},
+	}
+	for _, p := range times {
+		if v := c.QueryParam(p.name); v != "" {
+			t, err := parseTimeParam(v, p.end)
+			if err != nil {
+				return filter, echo.NewHTTPError(http.StatusBadRequest, "invalid "+p.name)
+			}
+			*p.dst = t
+		}
+	}
+
+	ids := []struct {
+		name string
+		dst  *uint
+	}{{"author_id", &filter.AuthorID}, {"tag_id", &filter.TagID}}
+	for _, p := range ids {
+		if v := c.QueryParam(p.name); v != "" {
+			id, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				return filter, echo.NewHTTPError(http.StatusBadRequest, "invalid "+p.name)
+			}
+			*p.dst = uint(id)
+		}
+	}
+	return filter, nil
+}
+
+// parseTimeParam reads an RFC 3339 time or a date, in UTC. A date stands for
+// its first instant, or its last one as the end of a range.
+func parseTimeParam(v string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return day, nil
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	libMiddleWare "github.com/abushaista/lms-backend/delivery/middleware"
+	"github.com/abushaista/lms-backend/delivery/utils"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/abushaista/lms-backend/internal/usecase"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog"
+)
+
+// maxImportUpload bounds the size of an import request body.
+const maxImportUpload = "20M"
+
+type BookImportHandler struct {
+	uc         *usecase.BookImportUseCase
+	rootLogger zerolog.Logger
+}
+
+func NewBookImportHandler(e *echo.Group, uc *usecase.BookImportUseCase, logger zerolog.Logger) {
+	h := &BookImportHandler{
+		uc:         uc,
+		rootLogger: logger,
+	}
+	e.POST("/books/import", h.Import, libMiddleWare.RequireStaff, middleware.BodyLimit(maxImportUpload))
+}
+
+// Import godoc
+// @Summary      Bulk import books
+// @Description  Upload a CSV (header row with title, author, isbn, year, summary, cover_image, category or category_id) or NDJSON file. Books are matched by ISBN and created or updated; each row is validated like a single book and reported on its own. Librarians and admins only.
+// @Tags         books
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file               formData  file    true   "CSV or NDJSON file"
+// @Param        format             query     string  false  "csv or ndjson, defaults to the file extension"
+// @Param        dry_run            query     bool    false  "Validate and report without writing"
+// @Param        create_categories  query     bool    false  "Create categories that do not exist yet"
+// @Success      200                {object}  domain.ImportReport
+// @Failure      400                {object}  utils.Problem
+// @Failure      403                {object}  utils.Problem
+// @Failure      413                {object}  utils.Problem
+// @Failure      500                {object}  utils.Problem
+// @Router       /books/import [post]
+func (h *BookImportHandler) Import(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	file, err := c.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "missing file")
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+	}
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+	createCategories, _ := strconv.ParseBool(c.QueryParam("create_categories"))
+
+	src, err := file.Open()
+	if err != nil {
+		logger.Error().Err(err).Msg("open upload")
+		return err
+	}
+	defer src.Close()
+
+	report, err := h.uc.Import(src, format, dto.BookImportOptions{
+		DryRun:           dryRun,
+		CreateCategories: createCategories,
+	})
+	if err != nil {
+		logger.Warn().Err(err).Str("format", format).Msg("import failed")
+		return err
+	}
+	logger.Info().Bool("dry_run", dryRun).Int("created", report.Created).Int("updated", report.Updated).
+		Int("failed", report.Failed).Msg("books imported")
+	return c.JSON(http.StatusOK, report)
+}
@@ -4,7 +4,8 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/abushaista/lms-backend/delivery/utils"
+	libMiddleWare "github.com/abushaista/lms-backend/delivery/middleware"
+	"github.com/abushaista/lms-backend/internal/domain"
 	"github.com/abushaista/lms-backend/internal/dto"
 	"github.com/abushaista/lms-backend/internal/usecase"
 	"github.com/go-playground/validator/v10"
@@ -21,11 +22,17 @@ func NewCategoryHandler(e *echo.Group, uc *usecase.CategoryUseCase) {
 		uc:       uc,
 		validate: validator.New(),
 	}
-	e.POST("/categories", h.CreateCategory)
-	e.PUT("/categories/:id", h.UpdateCategory)
-	e.DELETE("categories/:id", h.Delete)
+	e.POST("/categories", h.CreateCategory, libMiddleWare.RequireStaff)
+	e.PUT("/categories/:id", h.UpdateCategory, libMiddleWare.RequireStaff)
+	e.DELETE("/categories/:id", h.Delete, libMiddleWare.RequireStaff)
+	e.PATCH("/categories/:id", h.PatchCategory, libMiddleWare.RequireStaff)
+	e.PUT("/categories/:id/parent", h.Move, libMiddleWare.RequireStaff)
+	e.POST("/categories/:id/merge", h.Merge, libMiddleWare.RequireStaff)
+	e.GET("/categories/:id/merges", h.GetMerges, libMiddleWare.RequireStaff)
 	e.GET("/categories", h.GetByFilterAll)
+	e.GET("/categories/tree", h.Tree)
 	e.GET("/categories/:id", h.GetByID)
+	e.GET("/categories/:id/tree", h.Subtree)
 }
 
 // CreateCategory godoc
@@ -36,134 +43,347 @@ func NewCategoryHandler(e *echo.Group, uc *usecase.CategoryUseCase) {
 // @Produce      json
 // @Param        body  body      dto.CategoryRequest  true  "Category payload"
 // @Success      201   {object}  domain.Category
-// @Failure      400   {object}  map[string]interface{}
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  utils.Problem
+// @Failure      403   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      409   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
 // @Router       /categories [post]
 func (h *CategoryHandler) CreateCategory(c echo.Context) error {
 	var req dto.CategoryRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request payload"})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
 	}
 	if err := c.Validate(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, utils.FormatValidationErrors(err))
+		return err
 	}
 	req.ID = 0
 	category, err := h.uc.Save(req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+		return err
 	}
+	c.Response().Header().Set("ETag", categoryETag(category))
 	return c.JSON(http.StatusCreated, category)
 }
 
 // UpdateCategory godoc
 // @Summary      Update a category by ID
-// @Description  Update category details by its ID
+// @Description  Update category details by its ID. If-Match must carry the ETag the category was read with; 412 means someone else changed it in the meantime.
 // @Tags         categories
 // @Accept       json
 // @Produce      json
-// @Param        id    path      int               true  "Category ID"
-// @Param        body  body      dto.CategoryRequest  true  "Category payload"
-// @Success      200   {object}  domain.Category
-// @Failure      400   {object}  map[string]interface{}
-// @Failure      500   {object}  map[string]string
+// @Param        id        path      int                  true  "Category ID"
+// @Param        If-Match  header    string               true  "ETag of the category"
+// @Param        body      body      dto.CategoryRequest  true  "Category payload"
+// @Success      200       {object}  domain.Category
+// @Failure      400       {object}  utils.Problem
+// @Failure      403       {object}  utils.Problem
+// @Failure      404       {object}  utils.Problem
+// @Failure      409       {object}  utils.Problem
+// @Failure      412       {object}  utils.Problem
+// @Failure      428       {object}  utils.Problem
+// @Failure      500       {object}  utils.Problem
 // @Router       /categories/{id} [put]
 func (h *CategoryHandler) UpdateCategory(c echo.Context) error {
 	var req dto.CategoryRequest
 
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request payload"})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
 	}
 
 	if err := c.Validate(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, utils.FormatValidationErrors(err))
+		return err
 	}
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
 	}
 	req.ID = uint(id)
+	if req.Version, err = ifMatchVersion(c); err != nil {
+		return err
+	}
 	category, err := h.uc.Save(req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+		return err
 	}
+	c.Response().Header().Set("ETag", categoryETag(category))
 	return c.JSON(http.StatusOK, category)
 }
 
 // DeleteCategory godoc
 // @Summary      Delete a category by ID
-// @Description  Remove a category from the system by its ID
+// @Description  Remove a category from the system by its ID. A category that still has books is only deleted when they are reassigned to another category or cascaded, i.e. deleted with it; otherwise 409 is returned with the number of books. Cascading also returns 409 while one of the books has open loans or holds. Subcategories move up to the parent of the deleted category. If-Match must carry the ETag of the category, or * to delete any version.
 // @Tags         categories
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "Category ID"
-// @Success      200  {object}  map[string]string
-// @Failure      400  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Param        id           path      int     true   "Category ID"
+// @Param        If-Match     header    string  true   "ETag of the category"
+// @Param        reassign_to  query     int     false  "Move the books to this category"
+// @Param        cascade      query     bool    false  "Delete the books with the category"
+// @Success      200          {object}  map[string]string
+// @Failure      400          {object}  utils.Problem
+// @Failure      403          {object}  utils.Problem
+// @Failure      404          {object}  utils.Problem
+// @Failure      409          {object}  utils.Problem
+// @Failure      412          {object}  utils.Problem
+// @Failure      428          {object}  utils.Problem
+// @Failure      500          {object}  utils.Problem
 // @Router       /categories/{id} [delete]
 func (h *CategoryHandler) Delete(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	var d domain.CategoryDeletion
+	if v := c.QueryParam("reassign_to"); v != "" {
+		target, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid reassign_to")
+		}
+		d.ReassignTo = uint(target)
+	}
+	d.Cascade, _ = strconv.ParseBool(c.QueryParam("cascade"))
+	if d.Cascade && d.ReassignTo != 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "reassign_to and cascade cannot be combined")
 	}
-	if err := h.uc.Delete(uint(id)); err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	if d.Version, err = ifMatchVersion(c); err != nil {
+		return err
+	}
+	if err := h.uc.Delete(uint(id), d); err != nil {
+		return err
 	}
 	return c.JSON(http.StatusOK, map[string]string{"message": "Category deleted"})
 }
 
 // GetByFilterAll godoc
 // @Summary      Get categories by filter with pagination
-// @Description  Retrieve a list of categories filtered by a search string with pagination
+// @Description  Retrieve a list of categories filtered by a search string with pagination. Pages are selected by number or by the next_cursor and prev_cursor of the previous response; the Link header links the first, next and previous pages.
 // @Tags         categories
 // @Accept       json
 // @Produce      json
-// @Param        page    query     int     false  "Page number"     default(1)
-// @Param        limit   query     int     false  "Items per page"  default(10)
+// @Param        page    query     int     false  "Page number"                  default(1)
+// @Param        limit   query     int     false  "Items per page, at most 100"  default(10)
+// @Param        sort    query     string  false  "Sort fields among id and name, - for descending, as in -name"
+// @Param        cursor  query     string  false  "next_cursor or prev_cursor of a previous page"
 // @Param        filter  query     string  false  "Filter string"
 // @Success      200     {object}  map[string]interface{}
-// @Failure      500     {object}  map[string]string
+// @Header       200     {string}  Link  "Links to the first, next and previous pages"
+// @Failure      400     {object}  utils.Problem
+// @Failure      500     {object}  utils.Problem
 // @Router       /categories [get]
 func (h *CategoryHandler) GetByFilterAll(c echo.Context) error {
-	page, _ := strconv.Atoi(c.QueryParam("page"))
-	limit, _ := strconv.Atoi(c.QueryParam("limit"))
-
-	if page <= 0 {
-		page = 1
-	}
-	if limit <= 0 {
-		limit = 10
+	q, err := pageQuery(c, domain.CategorySortFields)
+	if err != nil {
+		return err
 	}
 	filter := c.QueryParam("filter")
-	categories, total, err := h.uc.GetByFilterAll(page, limit, filter)
+	categories, err := h.uc.GetByFilterAll(filter, q)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"data":  categories,
-		"total": total,
-		"page":  page,
-	})
+	return writePage(c, q, categories)
 }
 
 // GetByID godoc
 // @Summary      Get a category by ID
-// @Description  Retrieve a single category by its ID
+// @Description  Retrieve a single category by its ID. The ETag response header is required as If-Match to update or delete the category; sending it as If-None-Match answers 304 while the category is unchanged.
 // @Tags         categories
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "Category ID"
-// @Success      200  {object}  domain.Category
-// @Failure      400  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Param        id             path      int     true   "Category ID"
+// @Param        If-None-Match  header    string  false  "ETag of the copy held by the client"
+// @Success      200            {object}  domain.Category
+// @Success      304            {string}  string  "Not Modified"
+// @Failure      400            {object}  utils.Problem
+// @Failure      404            {object}  utils.Problem
+// @Failure      500            {object}  utils.Problem
 // @Router       /categories/{id} [get]
 func (h *CategoryHandler) GetByID(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
 	}
 	category, err := h.uc.GetByID(uint(id))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+		return err
+	}
+	if notModified(c, categoryETag(category)) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSON(http.StatusOK, category)
+}
+
+// PatchCategory godoc
+// @Summary      Partially update a category
+// @Description  Change some fields of a category with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902), told apart by the Content-Type. The result is validated like a PUT. If-Match must carry the ETag the category was read with. A JSON Patch whose paths are missing or whose test operations fail is answered with 409.
+// @Tags         categories
+// @Accept       application/merge-patch+json,application/json-patch+json
+// @Produce      json
+// @Param        id        path      int                  true  "Category ID"
+// @Param        If-Match  header    string               true  "ETag of the category"
+// @Param        body      body      dto.CategoryRequest  true  "Merge patch of the fields to change, or JSON Patch operations"
+// @Success      200       {object}  domain.Category
+// @Failure      400       {object}  utils.Problem
+// @Failure      403       {object}  utils.Problem
+// @Failure      404       {object}  utils.Problem
+// @Failure      409       {object}  utils.Problem
+// @Failure      412       {object}  utils.Problem
+// @Failure      415       {object}  utils.Problem
+// @Failure      428       {object}  utils.Problem
+// @Failure      500       {object}  utils.Problem
+// @Router       /categories/{id} [patch]
+func (h *CategoryHandler) PatchCategory(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	patch, err := bindPatch(c)
+	if err != nil {
+		return err
+	}
+	category, err := h.uc.Patch(uint(id), version, func(req *dto.CategoryRequest) error {
+		return patch(req)
+	})
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set("ETag", categoryETag(category))
+	return c.JSON(http.StatusOK, category)
+}
+
+// Move godoc
+// @Summary      Move a category
+// @Description  Place a category, with everything below it, under another category, or at the top level when parent_id is null. A category cannot be moved under itself or its descendants.
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                      true  "Category ID"
+// @Param        body  body      dto.MoveCategoryRequest  true  "New parent"
+// @Success      200   {object}  domain.Category
+// @Failure      400   {object}  utils.Problem
+// @Failure      403   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      409   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /categories/{id}/parent [put]
+func (h *CategoryHandler) Move(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	var req dto.MoveCategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	category, err := h.uc.Move(uint(id), req.ParentID)
+	if err != nil {
+		return err
 	}
+	c.Response().Header().Set("ETag", categoryETag(category))
 	return c.JSON(http.StatusOK, category)
 }
+
+// Merge godoc
+// @Summary      Merge categories
+// @Description  Merge the source categories into this one: their books and subcategories move here and the sources are deleted, all in one transaction. Each source is recorded in the merge audit trail.
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                         true  "Target category ID"
+// @Param        body  body      dto.MergeCategoriesRequest  true  "Source categories"
+// @Success      200   {array}   domain.CategoryMerge
+// @Failure      400   {object}  utils.Problem
+// @Failure      401   {object}  utils.Problem
+// @Failure      403   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      409   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /categories/{id}/merge [post]
+func (h *CategoryHandler) Merge(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+	var req dto.MergeCategoriesRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	merges, err := h.uc.Merge(uint(id), req, userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, merges)
+}
+
+// GetMerges godoc
+// @Summary      Get the merge history of a category
+// @Description  List the categories merged into this one, newest first
+// @Tags         categories
+// @Produce      json
+// @Param        id   path      int  true  "Category ID"
+// @Success      200  {array}   domain.CategoryMerge
+// @Failure      400  {object}  utils.Problem
+// @Failure      403  {object}  utils.Problem
+// @Failure      500  {object}  utils.Problem
+// @Router       /categories/{id}/merges [get]
+func (h *CategoryHandler) GetMerges(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	merges, err := h.uc.GetMerges(uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, merges)
+}
+
+// Tree godoc
+// @Summary      Get the category tree
+// @Description  Retrieve all categories as a tree: the top-level categories with their descendants nested in children, ordered by name
+// @Tags         categories
+// @Produce      json
+// @Success      200  {array}   domain.Category
+// @Failure      500  {object}  utils.Problem
+// @Router       /categories/tree [get]
+func (h *CategoryHandler) Tree(c echo.Context) error {
+	tree, err := h.uc.Tree()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, tree)
+}
+
+// Subtree godoc
+// @Summary      Get a category subtree
+// @Description  Retrieve a category with its descendants nested in children, ordered by name
+// @Tags         categories
+// @Produce      json
+// @Param        id   path      int  true  "Category ID"
+// @Success      200  {object}  domain.Category
+// @Failure      400  {object}  utils.Problem
+// @Failure      404  {object}  utils.Problem
+// @Failure      500  {object}  utils.Problem
+// @Router       /categories/{id}/tree [get]
+func (h *CategoryHandler) Subtree(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	tree, err := h.uc.Subtree(uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, tree)
+}
@@ -31,11 +31,11 @@ func (h *CleanUpHandler) CleanUpUrl(c echo.Context) error {
 	var req dto.CleanUpRequest
 	if err := c.Bind(&req); err != nil {
 		logger.Error().Err(err).Msg("invalid payload")
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request payload"})
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
 	}
 	if err := c.Validate(&req); err != nil {
 		logger.Error().Err(err).Msg("invalid payload")
-		return c.JSON(http.StatusBadRequest, utils.FormatValidationErrors(err))
+		return err
 	}
 
 	processedUrl := req.Url
@@ -43,7 +43,7 @@ func (h *CleanUpHandler) CleanUpUrl(c echo.Context) error {
 	if req.Operation == "canonical" || req.Operation == "all" {
 		url, err := h.uc.CanonicalURL(processedUrl)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, utils.FormatValidationErrors(err))
+			return err
 		}
 		processedUrl = url
 	}
@@ -51,7 +51,7 @@ func (h *CleanUpHandler) CleanUpUrl(c echo.Context) error {
 	if req.Operation == "redirection" || req.Operation == "all" {
 		url, err := h.uc.Redirection(processedUrl)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, utils.FormatValidationErrors(err))
+			return err
 		}
 		processedUrl = url
 	}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/abushaista/lms-backend/delivery/utils"
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// NewHTTPErrorHandler returns the echo error handler writing the response of
+// every error a handler returns, as an application/problem+json body. Domain
+// errors are answered by their kind; other errors are logged and answered
+// with a 500 that does not reveal them.
+func NewHTTPErrorHandler(logger zerolog.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+		p := problemFor(err)
+		p.Instance = c.Request().URL.Path
+		p.CorrelationID = utils.CorrelationID(c)
+		if p.Status >= http.StatusInternalServerError {
+			l := utils.WithRequestLogger(logger, c)
+			l.Error().Err(err).Int("status", p.Status).Msg("request failed")
+		}
+
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(p.Status)
+		} else {
+			c.Response().Header().Set(echo.HeaderContentType, utils.MIMEApplicationProblemJSON)
+			err = c.JSON(p.Status, p)
+		}
+		if err != nil {
+			l := utils.WithRequestLogger(logger, c)
+			l.Error().Err(err).Msg("write error response")
+		}
+	}
+}
+
+// problemFor describes an error as a problem, leaving out the details of
+// the request.
+func problemFor(err error) *utils.Problem {
+	var httpErr *echo.HTTPError
+	var validationErrs validator.ValidationErrors
+	var dup *domain.DuplicateISBNError
+	var inUse *domain.CategoryInUseError
+	switch {
+	case errors.As(err, &httpErr):
+		p := newProblem(httpErr.Code, statusCode(httpErr.Code))
+		if httpErr.Code < http.StatusInternalServerError {
+			p.Detail = fmt.Sprint(httpErr.Message)
+		}
+		return p
+	case errors.As(err, &validationErrs):
+		p := newProblem(http.StatusBadRequest, "validation_failed")
+		p.Detail = "the request has invalid fields"
+		p.Errors = utils.FormatValidationErrors(validationErrs)
+		return p
+	case errors.Is(err, domain.ErrMetadataUnavailable):
+		// the cause, logged as for any 5xx, may name hosts and addresses
+		p := newProblem(http.StatusBadGateway, "metadata_unavailable")
+		p.Detail = domain.ErrMetadataUnavailable.Error()
+		return p
+	}
+
+	code, message, ok := domain.Describe(err)
+	if !ok {
+		return newProblem(http.StatusInternalServerError, "internal_error")
+	}
+	p := newProblem(errorStatus(err), code)
+	p.Detail = message
+	switch {
+	case errors.As(err, &dup):
+		p.BookID = dup.BookID
+	case errors.As(err, &inUse):
+		p.Books = inUse.Books
+	}
+	return p
+}
+
+func newProblem(status int, code string) *utils.Problem {
+	return &utils.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+	}
+}
+
+// statusCode turns a status into a code, "Not Found" into not_found.
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// errorStatus returns the status code of a domain error. The few errors whose
+// status is more specific than that of their kind are listed first.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrImportTooLarge), errors.Is(err, domain.ErrImageTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrUnsupportedImage):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/labstack/echo/v4"
+)
+
+// bookETag identifies a representation of a book. Besides the version it
+// carries the copy counts, which change with loans without changing the
+// book, so that revalidating clients see them.
+func bookETag(b *domain.Book) string {
+	return fmt.Sprintf(`"%d-%d-%d"`, b.Version, b.TotalCopies, b.AvailableCopies)
+}
+
+func categoryETag(c *domain.Category) string {
+	return fmt.Sprintf(`"%d"`, c.Version)
+}
+
+// notModified sets the ETag of the response and reports whether the client
+// already has that representation, as told by If-None-Match.
+func notModified(c echo.Context, etag string) bool {
+	c.Response().Header().Set("ETag", etag)
+	header := c.Request().Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion returns the version named by the required If-Match header,
+// or 0 for "*". Only the version part of an ETag is compared; the copy
+// counts in a book's ETag do not concern writes.
+func ifMatchVersion(c echo.Context) (int64, error) {
+	header := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if header == "" {
+		return 0, echo.NewHTTPError(http.StatusPreconditionRequired, "If-Match header with the ETag of the record is required")
+	}
+	if header == "*" {
+		return 0, nil
+	}
+	// a weak or malformed ETag never matches
+	tag := strings.TrimSuffix(strings.TrimPrefix(header, `"`), `"`)
+	version, _, _ := strings.Cut(tag, "-")
+	v, err := strconv.ParseInt(version, 10, 64)
+	if err != nil || v <= 0 || len(tag)+2 != len(header) {
+		return 0, domain.ErrVersionMismatch
+	}
+	return v, nil
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	libMiddleWare "github.com/abushaista/lms-backend/delivery/middleware"
+	"github.com/abushaista/lms-backend/delivery/utils"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/abushaista/lms-backend/internal/usecase"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type FineHandler struct {
+	uc         *usecase.FineUseCase
+	rootLogger zerolog.Logger
+}
+
+func NewFineHandler(e *echo.Group, uc *usecase.FineUseCase, logger zerolog.Logger) {
+	h := &FineHandler{
+		uc:         uc,
+		rootLogger: logger,
+	}
+	e.GET("/fines/me", h.GetMine)
+	e.POST("/fines/:id/pay", h.Pay, libMiddleWare.RequireStaff)
+	e.POST("/fines/:id/waive", h.Waive, libMiddleWare.RequireStaff)
+}
+
+// GetMine godoc
+// @Summary      List my fines
+// @Description  Retrieve the fine ledger of the authenticated user and the outstanding balance in cents
+// @Tags         fines
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Failure      500  {object}  utils.Problem
+// @Router       /api/fines/me [get]
+func (h *FineHandler) GetMine(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+	fines, balance, err := h.uc.GetByUser(userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":        fines,
+		"outstanding": balance,
+	})
+}
+
+// Pay godoc
+// @Summary      Pay a fine
+// @Description  Record a payment in cents received from the member owing the fine. Librarians and admins only.
+// @Tags         fines
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                     true  "Fine ID"
+// @Param        body  body      dto.FinePaymentRequest  true  "Payment payload"
+// @Success      200   {object}  domain.Fine
+// @Failure      400   {object}  utils.Problem
+// @Failure      403   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      409   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /api/fines/{id}/pay [post]
+func (h *FineHandler) Pay(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	var req dto.FinePaymentRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Warn().Err(err).Msg("bind payment")
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	fine, err := h.uc.Pay(int64(id), req)
+	if err != nil {
+		logger.Warn().Err(err).Int("fine_id", id).Msg("payment failed")
+		return err
+	}
+	return c.JSON(http.StatusOK, fine)
+}
+
+// Waive godoc
+// @Summary      Waive a fine
+// @Description  Forgive part of a fine in cents, or all of it when amount is 0. Librarians and admins only.
+// @Tags         fines
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                    true  "Fine ID"
+// @Param        body  body      dto.FineWaiverRequest  true  "Waiver payload"
+// @Success      200   {object}  domain.Fine
+// @Failure      400   {object}  utils.Problem
+// @Failure      403   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      409   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /api/fines/{id}/waive [post]
+func (h *FineHandler) Waive(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	var req dto.FineWaiverRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Warn().Err(err).Msg("bind waiver")
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	fine, err := h.uc.Waive(int64(id), req)
+	if err != nil {
+		logger.Warn().Err(err).Int("fine_id", id).Msg("waiver failed")
+		return err
+	}
+	return c.JSON(http.StatusOK, fine)
+}
@@ -0,0 +1,186 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	libMiddleWare "github.com/abushaista/lms-backend/delivery/middleware"
+	"github.com/abushaista/lms-backend/delivery/utils"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/abushaista/lms-backend/internal/usecase"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type LoanHandler struct {
+	uc         *usecase.LoanUseCase
+	rootLogger zerolog.Logger
+}
+
+func NewLoanHandler(e *echo.Group, uc *usecase.LoanUseCase, logger zerolog.Logger) {
+	h := &LoanHandler{
+		uc:         uc,
+		rootLogger: logger,
+	}
+	e.POST("/loans", h.Checkout)
+	e.GET("/loans/me", h.GetMine)
+	e.GET("/loans/overdue", h.GetOverdue, libMiddleWare.RequireStaff)
+	e.POST("/loans/:id/return", h.Return, libMiddleWare.RequireStaff)
+	e.POST("/loans/:id/renew", h.Renew)
+}
+
+// Checkout godoc
+// @Summary      Check out a book
+// @Description  Borrow a book for the authenticated user. Fails with 409 when no copy is available and with 403 when the outstanding fines, including the fees accruing on overdue loans, exceed the borrowing limit.
+// @Tags         loans
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.CheckoutRequest  true  "Checkout payload"
+// @Success      201   {object}  domain.Loan
+// @Failure      400   {object}  utils.Problem
+// @Failure      403   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      409   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /api/loans [post]
+func (h *LoanHandler) Checkout(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	userID, err := currentUserID(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+	var req dto.CheckoutRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Warn().Err(err).Msg("bind loan")
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	loan, err := h.uc.Checkout(userID, req)
+	if err != nil {
+		logger.Warn().Err(err).Int64("book_id", req.BookID).Msg("checkout failed")
+		return err
+	}
+	return c.JSON(http.StatusCreated, loan)
+}
+
+// Return godoc
+// @Summary      Return a borrowed book
+// @Description  Check in the copy of an open loan, charge a fine when it is late and hand the copy to the next hold or put it back on the shelf. Librarians and admins only.
+// @Tags         loans
+// @Produce      json
+// @Param        id   path      int  true  "Loan ID"
+// @Success      200  {object}  domain.Loan
+// @Failure      400  {object}  utils.Problem
+// @Failure      403  {object}  utils.Problem
+// @Failure      404  {object}  utils.Problem
+// @Failure      409  {object}  utils.Problem
+// @Failure      500  {object}  utils.Problem
+// @Router       /api/loans/{id}/return [post]
+func (h *LoanHandler) Return(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	loan, err := h.uc.Return(int64(id))
+	if err != nil {
+		logger.Warn().Err(err).Int("loan_id", id).Msg("return failed")
+		return err
+	}
+	return c.JSON(http.StatusOK, loan)
+}
+
+// Renew godoc
+// @Summary      Renew a loan
+// @Description  Extend the due date of an open, non-overdue loan of the authenticated user while no one waits for the book
+// @Tags         loans
+// @Produce      json
+// @Param        id   path      int  true  "Loan ID"
+// @Success      200  {object}  domain.Loan
+// @Failure      400  {object}  utils.Problem
+// @Failure      403  {object}  utils.Problem
+// @Failure      404  {object}  utils.Problem
+// @Failure      409  {object}  utils.Problem
+// @Failure      500  {object}  utils.Problem
+// @Router       /api/loans/{id}/renew [post]
+func (h *LoanHandler) Renew(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	userID, err := currentUserID(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	loan, err := h.uc.Renew(userID, int64(id))
+	if err != nil {
+		logger.Warn().Err(err).Int("loan_id", id).Msg("renew failed")
+		return err
+	}
+	return c.JSON(http.StatusOK, loan)
+}
+
+// GetMine godoc
+// @Summary      List my loans
+// @Description  Retrieve the loans of the authenticated user with pagination
+// @Tags         loans
+// @Produce      json
+// @Param        page   query     int  false  "Page number"     default(1)
+// @Param        limit  query     int  false  "Items per page"  default(10)
+// @Success      200    {object}  map[string]interface{}
+// @Failure      500    {object}  utils.Problem
+// @Router       /api/loans/me [get]
+func (h *LoanHandler) GetMine(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+	page, limit := pageParams(c)
+	loans, total, err := h.uc.GetByUser(userID, page, limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  loans,
+		"total": total,
+		"page":  page,
+	})
+}
+
+// GetOverdue godoc
+// @Summary      List overdue loans
+// @Description  Retrieve all open loans whose due date has passed. Librarians and admins only.
+// @Tags         loans
+// @Produce      json
+// @Param        page   query     int  false  "Page number"     default(1)
+// @Param        limit  query     int  false  "Items per page"  default(10)
+// @Success      200    {object}  map[string]interface{}
+// @Failure      403    {object}  utils.Problem
+// @Failure      500    {object}  utils.Problem
+// @Router       /api/loans/overdue [get]
+func (h *LoanHandler) GetOverdue(c echo.Context) error {
+	page, limit := pageParams(c)
+	loans, total, err := h.uc.GetOverdue(page, limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  loans,
+		"total": total,
+		"page":  page,
+	})
+}
+
+// currentUserID returns the id of the caller set by middleware.UserContext.
+func currentUserID(c echo.Context) (uuid.UUID, error) {
+	user, ok := utils.CurrentUser(c)
+	if !ok {
+		return uuid.Nil, errors.New("missing user context")
+	}
+	return user.UserID, nil
+}
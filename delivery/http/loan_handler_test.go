@@ -0,0 +1,78 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	handler "github.com/abushaista/lms-backend/delivery/http"
+	"github.com/abushaista/lms-backend/delivery/utils"
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/usecase"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// loanStore is a domain.LoanRepository holding the loans in a map.
+type loanStore map[int64]*domain.Loan
+
+func (s loanStore) Checkout(l *domain.Loan) error { s[l.ID] = l; return nil }
+
+func (s loanStore) Return(l *domain.Loan, fine *domain.Fine) error { s[l.ID] = l; return nil }
+
+func (s loanStore) Renew(l *domain.Loan, dueAt time.Time) error { l.DueAt = dueAt; return nil }
+
+func (s loanStore) GetByID(id int64) (*domain.Loan, error) {
+	l, ok := s[id]
+	if !ok {
+		return nil, domain.ErrLoanNotFound
+	}
+	loan := *l
+	return &loan, nil
+}
+
+func (s loanStore) GetByUser(uuid.UUID, int, int) ([]*domain.Loan, int64, error) { return nil, 0, nil }
+
+func (s loanStore) GetOverdue(time.Time, int, int) ([]*domain.Loan, int64, error) { return nil, 0, nil }
+
+func (s loanStore) GetOverdueByUser(uuid.UUID, time.Time) ([]*domain.Loan, error) { return nil, nil }
+
+func TestLoanHandlerReturnRoles(t *testing.T) {
+	borrower := uuid.New()
+	tests := []struct {
+		name string
+		user *utils.UserContext
+		want int
+	}{
+		{"borrower", &utils.UserContext{UserID: borrower, Role: domain.RoleMember}, http.StatusForbidden},
+		{"other member", &utils.UserContext{UserID: uuid.New(), Role: domain.RoleMember}, http.StatusForbidden},
+		{"librarian", &utils.UserContext{UserID: uuid.New(), Role: domain.RoleLibrarian}, http.StatusOK},
+		{"admin", &utils.UserContext{UserID: uuid.New(), Role: domain.RoleAdmin}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			loans := loanStore{1: {ID: 1, UserID: borrower, BookID: 1, BorrowedAt: now, DueAt: now.Add(time.Hour)}}
+			e := echo.New()
+			api := e.Group("/api", func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.Set(utils.CtxUserKey, tt.user)
+					return next(c)
+				}
+			})
+			fines := usecase.NewFineUseCase(nil, domain.FinePolicy{})
+			handler.NewLoanHandler(api, usecase.NewLoanUseCase(loans, fines), zerolog.Nop())
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/loans/1/return", nil))
+			if rec.Code != tt.want {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if returned := loans[1].ReturnedAt != nil; returned != (tt.want == http.StatusOK) {
+				t.Errorf("got returned %v after status %d", returned, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPageSize = 10
+	// maxPageSize caps the limit query parameter of every list.
+	maxPageSize = 100
+)
+
+func pageParams(c echo.Context) (int, int) {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	return page, min(limit, maxPageSize)
+}
+
+// pageQuery reads the page, limit, sort and cursor query parameters of a
+// list that can be sorted by fields. A cursor carries the sort it was issued
+// for, so sort may be left out next to one but not changed.
+func pageQuery(c echo.Context, fields []string) (domain.PageQuery, error) {
+	page, limit := pageParams(c)
+	q := domain.PageQuery{Page: page, Limit: limit}
+	sort, err := parseSort(c.QueryParam("sort"), fields)
+	if err != nil {
+		return q, err
+	}
+	q.Sort = sort
+	if token := c.QueryParam("cursor"); token != "" {
+		cursor, cursorSort, err := decodeCursor(token)
+		if err != nil {
+			return q, domain.ErrInvalidCursor
+		}
+		if q.Sort == nil {
+			if q.Sort, err = parseSort(cursorSort, fields); err != nil {
+				return q, domain.ErrInvalidCursor
+			}
+		}
+		if formatSort(q.Sort) != cursorSort {
+			return q, domain.ErrInvalidCursor
+		}
+		q.Cursor = cursor
+	}
+	return q, nil
+}
+
+// parseSort reads a sort parameter such as "title,-year", where a leading
+// "-" sorts in descending order.
+func parseSort(param string, fields []string) ([]domain.Sort, error) {
+	if param == "" {
+		return nil, nil
+	}
+	var sort []domain.Sort
+	for _, field := range strings.Split(param, ",") {
+		s := domain.Sort{Field: strings.TrimSpace(field)}
+		if rest, ok := strings.CutPrefix(s.Field, "-"); ok {
+			s.Field, s.Desc = rest, true
+		}
+		if !slices.Contains(fields, s.Field) {
+			return nil, fmt.Errorf("%w: %q", domain.ErrInvalidSort, s.Field)
+		}
+		if slices.ContainsFunc(sort, func(o domain.Sort) bool { return o.Field == s.Field }) {
+			return nil, fmt.Errorf("%w: %q is given twice", domain.ErrInvalidSort, s.Field)
+		}
+		sort = append(sort, s)
+	}
+	return sort, nil
+}
+
+func formatSort(sort []domain.Sort) string {
+	fields := make([]string, len(sort))
+	for i, s := range sort {
+		fields[i] = s.Field
+		if s.Desc {
+			fields[i] = "-" + s.Field
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// cursorToken is the content of the opaque cursor query parameter.
+type cursorToken struct {
+	Sort string `json:"s,omitempty"`
+	*domain.Cursor
+}
+
+func encodeCursor(cursor *domain.Cursor, sort []domain.Sort) string {
+	data, _ := json.Marshal(cursorToken{Sort: formatSort(sort), Cursor: cursor})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (*domain.Cursor, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, "", err
+	}
+	t := cursorToken{Cursor: &domain.Cursor{}}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, "", err
+	}
+	return t.Cursor, t.Sort, nil
+}
+
+// writePage answers a list request with p in the data/total/page envelope,
+// page being left out for pages selected by cursor, along with the cursors
+// of the neighbouring pages. Those pages, and the first one, are also linked
+// from the Link header.
+func writePage[T any](c echo.Context, q domain.PageQuery, p *domain.Page[T]) error {
+	body := map[string]interface{}{
+		"data":        p.Items,
+		"total":       p.Total,
+		"next_cursor": nil,
+		"prev_cursor": nil,
+	}
+	if q.Cursor == nil {
+		body["page"] = q.Page
+	}
+
+	path := c.Request().URL.Path
+	params := c.Request().URL.Query()
+	params.Del("page")
+	params.Set("limit", strconv.Itoa(q.Limit))
+	if sort := formatSort(q.Sort); sort != "" {
+		params.Set("sort", sort)
+	}
+	link := func(rel, cursor string) string {
+		if cursor == "" {
+			params.Del("cursor")
+		} else {
+			params.Set("cursor", cursor)
+		}
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, path, params.Encode(), rel)
+	}
+	links := []string{link("first", "")}
+	if p.NextCursor != nil {
+		cursor := encodeCursor(p.NextCursor, q.Sort)
+		body["next_cursor"] = cursor
+		links = append(links, link("next", cursor))
+	}
+	if p.PrevCursor != nil {
+		cursor := encodeCursor(p.PrevCursor, q.Sort)
+		body["prev_cursor"] = cursor
+		links = append(links, link("prev", cursor))
+	}
+	c.Response().Header().Set("Link", strings.Join(links, ", "))
+	return c.JSON(http.StatusOK, body)
+}
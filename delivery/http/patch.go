@@ -0,0 +1,75 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+
+	"github.com/abushaista/lms-backend/delivery/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// bindPatch reads the body of a PATCH request, a JSON Merge Patch or a JSON
+// Patch as told by its Content-Type, and returns a function applying it to
+// v, the request of a full update filled with the current record. Members v
+// does not know are refused rather than dropped, so that patching a
+// read-only field such as the availability of a book fails loudly.
+func bindPatch(c echo.Context) (func(v any) error, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if mediaType != utils.MIMEApplicationMergePatchJSON && mediaType != utils.MIMEApplicationJSONPatchJSON {
+		return nil, echo.NewHTTPError(http.StatusUnsupportedMediaType,
+			"PATCH accepts "+utils.MIMEApplicationMergePatchJSON+" or "+utils.MIMEApplicationJSONPatchJSON)
+	}
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var apply func(doc any) (any, error)
+	if mediaType == utils.MIMEApplicationMergePatchJSON {
+		patch, err := utils.DecodeJSON(body)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid merge patch: "+err.Error())
+		}
+		apply = func(doc any) (any, error) { return utils.MergePatch(doc, patch), nil }
+	} else {
+		ops, err := utils.ParseJSONPatch(body)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		apply = func(doc any) (any, error) { return utils.ApplyJSONPatch(doc, ops) }
+	}
+
+	return func(v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		doc, err := utils.DecodeJSON(data)
+		if err != nil {
+			return err
+		}
+		doc, err = apply(doc)
+		if errors.Is(err, utils.ErrPatchConflict) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return err
+		}
+		// members removed by the patch must not keep their current value
+		reflect.ValueOf(v).Elem().SetZero()
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "the patched record is invalid: "+err.Error())
+		}
+		return nil
+	}, nil
+}
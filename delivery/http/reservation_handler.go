@@ -0,0 +1,141 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	libMiddleWare "github.com/abushaista/lms-backend/delivery/middleware"
+	"github.com/abushaista/lms-backend/delivery/utils"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/abushaista/lms-backend/internal/usecase"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type ReservationHandler struct {
+	uc         *usecase.ReservationUseCase
+	rootLogger zerolog.Logger
+}
+
+func NewReservationHandler(e *echo.Group, uc *usecase.ReservationUseCase, logger zerolog.Logger) {
+	h := &ReservationHandler{
+		uc:         uc,
+		rootLogger: logger,
+	}
+	e.POST("/reservations", h.Place)
+	e.GET("/reservations/me", h.GetMine)
+	e.DELETE("/reservations/:id", h.Cancel)
+	e.GET("/books/:id/reservations", h.GetQueue, libMiddleWare.RequireStaff)
+}
+
+// Place godoc
+// @Summary      Place a hold on a book
+// @Description  Join the FIFO hold queue of a book that has no copy available
+// @Tags         reservations
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.ReservationRequest  true  "Reservation payload"
+// @Success      201   {object}  domain.Reservation
+// @Failure      400   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      409   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /api/reservations [post]
+func (h *ReservationHandler) Place(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	userID, err := currentUserID(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+	var req dto.ReservationRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Warn().Err(err).Msg("bind reservation")
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	reservation, err := h.uc.Place(userID, req)
+	if err != nil {
+		logger.Warn().Err(err).Int64("book_id", req.BookID).Msg("place hold failed")
+		return err
+	}
+	return c.JSON(http.StatusCreated, reservation)
+}
+
+// Cancel godoc
+// @Summary      Cancel a hold
+// @Description  Withdraw an active hold of the authenticated user
+// @Tags         reservations
+// @Produce      json
+// @Param        id   path      int  true  "Reservation ID"
+// @Success      200  {object}  domain.Reservation
+// @Failure      400  {object}  utils.Problem
+// @Failure      403  {object}  utils.Problem
+// @Failure      404  {object}  utils.Problem
+// @Failure      409  {object}  utils.Problem
+// @Failure      500  {object}  utils.Problem
+// @Router       /api/reservations/{id} [delete]
+func (h *ReservationHandler) Cancel(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	userID, err := currentUserID(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	reservation, err := h.uc.Cancel(userID, int64(id))
+	if err != nil {
+		logger.Warn().Err(err).Int("reservation_id", id).Msg("cancel hold failed")
+		return err
+	}
+	return c.JSON(http.StatusOK, reservation)
+}
+
+// GetMine godoc
+// @Summary      List my holds
+// @Description  Retrieve the holds of the authenticated user with their queue position
+// @Tags         reservations
+// @Produce      json
+// @Success      200  {array}   domain.Reservation
+// @Failure      500  {object}  utils.Problem
+// @Router       /api/reservations/me [get]
+func (h *ReservationHandler) GetMine(c echo.Context) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+	reservations, err := h.uc.GetByUser(userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, reservations)
+}
+
+// GetQueue godoc
+// @Summary      List the hold queue of a book
+// @Description  Retrieve the active holds of a book in pickup order with their queue position. Librarians and admins only.
+// @Tags         reservations
+// @Produce      json
+// @Param        id   path      int  true  "Book ID"
+// @Success      200  {array}   domain.Reservation
+// @Failure      400  {object}  utils.Problem
+// @Failure      403  {object}  utils.Problem
+// @Failure      404  {object}  utils.Problem
+// @Failure      500  {object}  utils.Problem
+// @Router       /books/{id}/reservations [get]
+func (h *ReservationHandler) GetQueue(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	reservations, err := h.uc.GetQueue(int64(id))
+	if err != nil {
+		logger.Warn().Err(err).Msg("list hold queue")
+		return err
+	}
+	return c.JSON(http.StatusOK, reservations)
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	libMiddleWare "github.com/abushaista/lms-backend/delivery/middleware"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/abushaista/lms-backend/internal/usecase"
+	"github.com/labstack/echo/v4"
+)
+
+type TagHandler struct {
+	uc *usecase.TagUseCase
+}
+
+func NewTagHandler(e *echo.Group, uc *usecase.TagUseCase) {
+	h := &TagHandler{uc: uc}
+	e.POST("/tags", h.CreateTag, libMiddleWare.RequireStaff)
+	e.PUT("/tags/:id", h.UpdateTag, libMiddleWare.RequireStaff)
+	e.DELETE("/tags/:id", h.Delete, libMiddleWare.RequireStaff)
+	e.GET("/tags", h.GetByFilterAll)
+	e.GET("/tags/:id", h.GetByID)
+}
+
+// CreateTag godoc
+// @Summary      Create a tag
+// @Description  Add a tag that books can be linked to
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.TagRequest  true  "Tag payload"
+// @Success      201   {object}  domain.Tag
+// @Failure      400   {object}  utils.Problem
+// @Failure      403   {object}  utils.Problem
+// @Failure      409   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /tags [post]
+func (h *TagHandler) CreateTag(c echo.Context) error {
+	var req dto.TagRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	req.ID = 0
+	tag, err := h.uc.Save(req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, tag)
+}
+
+// UpdateTag godoc
+// @Summary      Rename a tag
+// @Description  Update the name of a tag
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int             true  "Tag ID"
+// @Param        body  body      dto.TagRequest  true  "Tag payload"
+// @Success      200   {object}  domain.Tag
+// @Failure      400   {object}  utils.Problem
+// @Failure      403   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      409   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /tags/{id} [put]
+func (h *TagHandler) UpdateTag(c echo.Context) error {
+	var req dto.TagRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	req.ID = uint(id)
+	tag, err := h.uc.Save(req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, tag)
+}
+
+// Delete godoc
+// @Summary      Delete a tag
+// @Description  Remove a tag and unlink it from its books
+// @Tags         tags
+// @Produce      json
+// @Param        id   path      int  true  "Tag ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  utils.Problem
+// @Failure      403  {object}  utils.Problem
+// @Failure      404  {object}  utils.Problem
+// @Failure      500  {object}  utils.Problem
+// @Router       /tags/{id} [delete]
+func (h *TagHandler) Delete(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	if err := h.uc.Delete(uint(id)); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Tag deleted"})
+}
+
+// GetByFilterAll godoc
+// @Summary      Get tags with pagination
+// @Description  Retrieve tags ordered by name, optionally filtered by a part of their name
+// @Tags         tags
+// @Produce      json
+// @Param        page    query     int     false  "Page number"     default(1)
+// @Param        limit   query     int     false  "Items per page"  default(10)
+// @Param        filter  query     string  false  "Filter string"
+// @Success      200     {object}  map[string]interface{}
+// @Failure      500     {object}  utils.Problem
+// @Router       /tags [get]
+func (h *TagHandler) GetByFilterAll(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	tags, total, err := h.uc.GetByFilterAll(page, limit, c.QueryParam("filter"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  tags,
+		"total": total,
+		"page":  page,
+	})
+}
+
+// GetByID godoc
+// @Summary      Get a tag by ID
+// @Description  Retrieve a single tag by its ID
+// @Tags         tags
+// @Produce      json
+// @Param        id   path      int  true  "Tag ID"
+// @Success      200  {object}  domain.Tag
+// @Failure      400  {object}  utils.Problem
+// @Failure      404  {object}  utils.Problem
+// @Failure      500  {object}  utils.Problem
+// @Router       /tags/{id} [get]
+func (h *TagHandler) GetByID(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	tag, err := h.uc.GetByID(uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, tag)
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"net/http"
+
+	libMiddleWare "github.com/abushaista/lms-backend/delivery/middleware"
+	"github.com/abushaista/lms-backend/delivery/utils"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/abushaista/lms-backend/internal/usecase"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type UserHandler struct {
+	uc         *usecase.AuthUseCase
+	rootLogger zerolog.Logger
+}
+
+func NewUserHandler(e *echo.Group, uc *usecase.AuthUseCase, logger zerolog.Logger) {
+	h := &UserHandler{
+		uc:         uc,
+		rootLogger: logger,
+	}
+	e.PUT("/users/:id/role", h.UpdateRole, libMiddleWare.RequireAdmin)
+}
+
+// UpdateRole godoc
+// @Summary      Change the role of a user
+// @Description  Grant the admin, librarian or member role to a user. Admins only; takes effect on the next login.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                 true  "User ID"
+// @Param        body  body      dto.UpdateRoleRequest  true  "Role payload"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  utils.Problem
+// @Failure      403   {object}  utils.Problem
+// @Failure      404   {object}  utils.Problem
+// @Failure      500   {object}  utils.Problem
+// @Router       /api/users/{id}/role [put]
+func (h *UserHandler) UpdateRole(c echo.Context) error {
+	logger := utils.WithRequestLogger(h.rootLogger, c)
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	var req dto.UpdateRoleRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Warn().Err(err).Msg("bind role")
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	if err := h.uc.UpdateRole(id, req); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "role updated"})
+}
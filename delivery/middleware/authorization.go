@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/abushaista/lms-backend/delivery/utils"
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/labstack/echo/v4"
+)
+
+// RequireRoles lets the request through only when the role of the caller set
+// by UserContext is one of roles. Tokens without a role act as members.
+func RequireRoles(roles ...domain.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user, ok := utils.CurrentUser(c)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing token")
+			}
+			if !slices.Contains(roles, user.Role) {
+				return echo.NewHTTPError(http.StatusForbidden, "insufficient role")
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequireStaff allows librarians and admins.
+var RequireStaff = RequireRoles(domain.RoleLibrarian, domain.RoleAdmin)
+
+// RequireAdmin allows admins only.
+var RequireAdmin = RequireRoles(domain.RoleAdmin)
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/abushaista/lms-backend/delivery/utils"
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// UserContext turns the claims of the token validated by echojwt into a
+// utils.UserContext, stored in the echo context and in the request context,
+// and refreshes the request logger so it carries the user. It must run after
+// echojwt.
+func UserContext(root zerolog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, ok := c.Get(utils.CtxTokenKey).(*jwt.Token)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing token")
+			}
+			claims, ok := token.Claims.(jwt.MapClaims)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+			}
+			sub, _ := claims["user_id"].(string)
+			id, err := uuid.Parse(sub)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+			}
+			user := &utils.UserContext{UserID: id, Role: domain.RoleMember}
+			user.Username, _ = claims["username"].(string)
+			user.SessionID, _ = claims["sid"].(string)
+			if r, _ := claims["role"].(string); r != "" {
+				user.Role = domain.Role(r)
+			}
+
+			c.Set(utils.CtxUserKey, user)
+			c.SetRequest(c.Request().WithContext(domain.WithUser(c.Request().Context(), user)))
+			c.Set(utils.CtxLoggerKey, utils.WithRequestLogger(root, c))
+			return next(c)
+		}
+	}
+}
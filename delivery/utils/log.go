@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"github.com/abushaista/lms-backend/internal/domain"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog"
 )
@@ -9,30 +10,30 @@ const (
 	CtxLoggerKey = "logger"
 	CtxCorrKey   = "correlation_id"
 	CtxUserKey   = "user" // if you store user in context using middleware.UserContext
+	CtxTokenKey  = "jwt"  // raw *jwt.Token stored by echojwt
 )
 
 func WithRequestLogger(root zerolog.Logger, c echo.Context) zerolog.Logger {
-	// correlation id
-	corr, _ := c.Get(CtxCorrKey).(string)
-	if corr == "" {
-		corr = c.Request().Header.Get("X-Correlation-ID")
-	}
-
-	l := root.With().Str("correlation_id", corr)
+	l := root.With().Str("correlation_id", CorrelationID(c))
 
 	// if user context present, also attach user_id and username
 	if u := c.Get(CtxUserKey); u != nil {
 		if uc, ok := u.(*UserContext); ok {
-			l = l.Str("user_id", uc.UserID).Str("username", uc.Username)
+			l = l.Str("user_id", uc.UserID.String()).Str("username", uc.Username)
 		}
 	}
 
 	return l.Logger()
 }
 
-type UserContext struct {
-	UserID   string
-	Username string
+// UserContext is the authenticated caller, built from the validated JWT claims.
+type UserContext = domain.UserContext
+
+// CurrentUser returns the authenticated caller stored by middleware.UserContext.
+// Use cases read it from the request context with domain.UserFrom.
+func CurrentUser(c echo.Context) (*UserContext, bool) {
+	uc, ok := c.Get(CtxUserKey).(*UserContext)
+	return uc, ok && uc != nil
 }
 
 // GetLogger returns request-scoped logger stored in context, or root logger.
@@ -0,0 +1,334 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Media types of the PATCH bodies understood by the API.
+const (
+	MIMEApplicationMergePatchJSON = "application/merge-patch+json"
+	MIMEApplicationJSONPatchJSON  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch means the patch document itself is malformed.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrPatchConflict means a well-formed patch does not apply to the
+	// document: a path is missing or a test operation does not hold.
+	ErrPatchConflict = errors.New("patch does not apply")
+)
+
+// DecodeJSON decodes a JSON value into maps, slices and json.Number, the
+// form MergePatch and ApplyJSONPatch work on.
+func DecodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return v, nil
+}
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to doc and returns the
+// result; doc may be modified in place.
+func MergePatch(doc, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	d, ok := doc.(map[string]any)
+	if !ok {
+		d = map[string]any{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(d, key)
+			continue
+		}
+		d[key] = MergePatch(d[key], value)
+	}
+	return d
+}
+
+// PatchOperation is one operation of a JSON Patch (RFC 6902).
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+
+	path, from []string
+	value      any
+}
+
+// ParseJSONPatch decodes a JSON Patch and checks that every operation is
+// complete, so that a bad patch is refused before anything is applied.
+func ParseJSONPatch(data []byte) ([]PatchOperation, error) {
+	var ops []PatchOperation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	for i := range ops {
+		op := &ops[i]
+		var err error
+		if op.path, err = parsePointer(op.Path); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+		}
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("%w: operation %d: %s needs a value", ErrInvalidPatch, i, op.Op)
+			}
+			if op.value, err = DecodeJSON(op.Value); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+			}
+		case "move", "copy":
+			if op.from, err = parsePointer(op.From); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: from: %v", ErrInvalidPatch, i, err)
+			}
+			if op.Op == "move" && len(op.from) < len(op.path) && slices.Equal(op.from, op.path[:len(op.from)]) {
+				return nil, fmt.Errorf("%w: operation %d: cannot move a value into itself", ErrInvalidPatch, i)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("%w: operation %d: unknown op %q", ErrInvalidPatch, i, op.Op)
+		}
+	}
+	return ops, nil
+}
+
+// ApplyJSONPatch applies operations parsed by ParseJSONPatch to doc and
+// returns the result; doc may be modified in place. Either every operation
+// applies or an error is returned.
+func ApplyJSONPatch(doc any, ops []PatchOperation) (any, error) {
+	for i, op := range ops {
+		var err error
+		switch op.Op {
+		case "add":
+			doc, err = addValue(doc, op.path, cloneJSON(op.value))
+		case "remove":
+			doc, _, err = removeValue(doc, op.path)
+		case "replace":
+			if doc, _, err = removeValue(doc, op.path); err == nil {
+				doc, err = addValue(doc, op.path, cloneJSON(op.value))
+			}
+		case "move":
+			var v any
+			if doc, v, err = removeValue(doc, op.from); err == nil {
+				doc, err = addValue(doc, op.path, v)
+			}
+		case "copy":
+			var v any
+			if v, err = getValue(doc, op.from); err == nil {
+				doc, err = addValue(doc, op.path, cloneJSON(v))
+			}
+		case "test":
+			var v any
+			if v, err = getValue(doc, op.path); err == nil && !equalJSON(v, op.value) {
+				err = fmt.Errorf("%s does not hold the tested value", op.Path)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrPatchConflict, i, err)
+		}
+	}
+	return doc, nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped tokens;
+// the empty pointer is the whole document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q does not start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses the index of an element of an array of length n; with
+// appending, n itself and "-" are allowed and mean past the last element.
+func arrayIndex(token string, n int, appending bool) (int, error) {
+	if appending && token == "-" {
+		return n, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > n || (i == n && !appending) {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func getValue(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch d := doc.(type) {
+		case map[string]any:
+			v, ok := d[token]
+			if !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+			doc = v
+		case []any:
+			i, err := arrayIndex(token, len(d), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = d[i]
+		default:
+			return nil, fmt.Errorf("cannot look up %q in a scalar", token)
+		}
+	}
+	return doc, nil
+}
+
+// addValue sets the member or inserts the array element at path and returns
+// the new doc.
+func addValue(doc any, path []string, v any) (any, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	token, rest := path[0], path[1:]
+	switch d := doc.(type) {
+	case map[string]any:
+		if len(rest) == 0 {
+			d[token] = v
+			return d, nil
+		}
+		child, ok := d[token]
+		if !ok {
+			return nil, fmt.Errorf("no member %q", token)
+		}
+		child, err := addValue(child, rest, v)
+		if err != nil {
+			return nil, err
+		}
+		d[token] = child
+		return d, nil
+	case []any:
+		i, err := arrayIndex(token, len(d), len(rest) == 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) == 0 {
+			return slices.Insert(d, i, v), nil
+		}
+		if d[i], err = addValue(d[i], rest, v); err != nil {
+			return nil, err
+		}
+		return d, nil
+	default:
+		return nil, fmt.Errorf("cannot add %q to a scalar", token)
+	}
+}
+
+// removeValue removes the value at path, which must exist, and returns the
+// new doc along with the removed value.
+func removeValue(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	token, rest := path[0], path[1:]
+	switch d := doc.(type) {
+	case map[string]any:
+		child, ok := d[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("no member %q", token)
+		}
+		if len(rest) == 0 {
+			delete(d, token)
+			return d, child, nil
+		}
+		child, removed, err := removeValue(child, rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		d[token] = child
+		return d, removed, nil
+	case []any:
+		i, err := arrayIndex(token, len(d), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rest) == 0 {
+			removed := d[i]
+			return slices.Delete(d, i, i+1), removed, nil
+		}
+		child, removed, err := removeValue(d[i], rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		d[i] = child
+		return d, removed, nil
+	default:
+		return nil, nil, fmt.Errorf("cannot remove %q from a scalar", token)
+	}
+}
+
+func cloneJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			m[key] = cloneJSON(value)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, value := range v {
+			s[i] = cloneJSON(value)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// equalJSON compares decoded JSON values, numbers by value, so that 1 and
+// 1.0 are equal as RFC 6902 requires of test.
+func equalJSON(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		bm, ok := b.(map[string]any)
+		if !ok || len(a) != len(bm) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := bm[key]
+			if !ok || !equalJSON(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bs, ok := b.([]any)
+		return ok && slices.EqualFunc(a, bs, equalJSON)
+	case json.Number:
+		bn, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		af, aerr := a.Float64()
+		bf, berr := bn.Float64()
+		return aerr == nil && berr == nil && af == bf
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,165 @@
+package utils_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/abushaista/lms-backend/delivery/utils"
+)
+
+// canonicalJSON re-encodes a JSON text with sorted members.
+func canonicalJSON(t *testing.T, text string) string {
+	t.Helper()
+	v, err := utils.DecodeJSON([]byte(text))
+	if err != nil {
+		t.Fatalf("%s: %v", text, err)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+// The examples of RFC 6902, appendix A, and a few more.
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string // the result, unless err is set
+		err   error
+	}{
+		{"A.1 add an object member", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`, nil},
+		{"A.2 add an array element", `{"foo":["bar","baz"]}`,
+			`[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`, nil},
+		{"A.3 remove an object member", `{"baz":"qux","foo":"bar"}`,
+			`[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`, nil},
+		{"A.4 remove an array element", `{"foo":["bar","qux","baz"]}`,
+			`[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`, nil},
+		{"A.5 replace a value", `{"baz":"qux","foo":"bar"}`,
+			`[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`, nil},
+		{"A.6 move a value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, nil},
+		{"A.7 move an array element", `{"foo":["all","grass","cows","eat"]}`,
+			`[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`, nil},
+		{"A.8 test a value, success", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`, nil},
+		{"A.9 test a value, error", `{"baz":"qux"}`,
+			`[{"op":"test","path":"/baz","value":"bar"}]`, ``, utils.ErrPatchConflict},
+		{"A.10 add a nested member object", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"child":{"grandchild":{}},"foo":"bar"}`, nil},
+		{"A.11 ignore unrecognized elements", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux","xyz":123}]`, `{"baz":"qux","foo":"bar"}`, nil},
+		{"A.12 add to a nonexistent target", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz/bat","value":"qux"}]`, ``, utils.ErrPatchConflict},
+		{"A.14 ~ escape ordering", `{"/":9,"~1":10}`,
+			`[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`, nil},
+		{"A.15 compare strings and numbers", `{"/":9,"~1":10}`,
+			`[{"op":"test","path":"/~01","value":"10"}]`, ``, utils.ErrPatchConflict},
+		{"A.16 add an array value", `{"foo":["bar"]}`,
+			`[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`, nil},
+
+		{"~1 escapes a slash", `{"a/b":1}`,
+			`[{"op":"replace","path":"/a~1b","value":2}]`, `{"a/b":2}`, nil},
+		{"~0 escapes a tilde", `{"m~n":1}`,
+			`[{"op":"remove","path":"/m~0n"}]`, `{}`, nil},
+		{"- appends to an array", `{"foo":[1,2]}`,
+			`[{"op":"add","path":"/foo/-","value":3}]`, `{"foo":[1,2,3]}`, nil},
+		{"- is no element to remove", `{"foo":[1,2]}`,
+			`[{"op":"remove","path":"/foo/-"}]`, ``, utils.ErrPatchConflict},
+		{"add at the length of an array", `{"foo":[1,2]}`,
+			`[{"op":"add","path":"/foo/2","value":3}]`, `{"foo":[1,2,3]}`, nil},
+		{"add past the end of an array", `{"foo":[1,2]}`,
+			`[{"op":"add","path":"/foo/3","value":3}]`, ``, utils.ErrPatchConflict},
+		{"leading zeros are no index", `{"foo":[1,2]}`,
+			`[{"op":"remove","path":"/foo/01"}]`, ``, utils.ErrPatchConflict},
+		{"copy a value", `{"foo":{"bar":1}}`,
+			`[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`,
+			`{"baz":{"bar":2},"foo":{"bar":1}}`, nil},
+		{"replace a missing member", `{"foo":1}`,
+			`[{"op":"replace","path":"/bar","value":2}]`, ``, utils.ErrPatchConflict},
+		{"test numbers by value", `{"n":1}`,
+			`[{"op":"test","path":"/n","value":1.0}]`, `{"n":1}`, nil},
+		{"replace the whole document", `{"foo":1}`,
+			`[{"op":"replace","path":"","value":[1]}]`, `[1]`, nil},
+		{"all or nothing", `{"foo":1}`,
+			`[{"op":"add","path":"/bar","value":2},{"op":"test","path":"/foo","value":2}]`, ``, utils.ErrPatchConflict},
+
+		{"unknown op", `{}`, `[{"op":"merge","path":"/a"}]`, ``, utils.ErrInvalidPatch},
+		{"add without a value", `{}`, `[{"op":"add","path":"/a"}]`, ``, utils.ErrInvalidPatch},
+		{"path without a slash", `{}`, `[{"op":"remove","path":"a"}]`, ``, utils.ErrInvalidPatch},
+		{"move into itself", `{"a":{}}`, `[{"op":"move","from":"/a","path":"/a/b"}]`, ``, utils.ErrInvalidPatch},
+		{"not an array of operations", `{}`, `{"op":"remove","path":"/a"}`, ``, utils.ErrInvalidPatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := utils.DecodeJSON([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ops, err := utils.ParseJSONPatch([]byte(tt.patch))
+			var got any
+			if err == nil {
+				got, err = utils.ApplyJSONPatch(doc, ops)
+			}
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("got %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, _ := json.Marshal(got)
+			if want := canonicalJSON(t, tt.want); string(out) != want {
+				t.Errorf("got %s, want %s", out, want)
+			}
+		})
+	}
+}
+
+// The examples of RFC 7396, appendix A.
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		// nulls only delete, they are never stored
+		{`{"a":{"b":1}}`, `{"a":{"c":null}}`, `{"a":{"b":1}}`},
+		{`{}`, `{"a":null}`, `{}`},
+	}
+	for _, tt := range tests {
+		doc, err := utils.DecodeJSON([]byte(tt.doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		patch, err := utils.DecodeJSON([]byte(tt.patch))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, _ := json.Marshal(utils.MergePatch(doc, patch))
+		if want := canonicalJSON(t, tt.want); string(out) != want {
+			t.Errorf("merging %s into %s: got %s, want %s", tt.patch, tt.doc, out, want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package utils
+
+import "github.com/labstack/echo/v4"
+
+// MIMEApplicationProblemJSON is the media type of Problem bodies.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Problem is the RFC 7807 body of every error response. Code is stable and
+// meant for clients to branch on; Title and Detail are for humans.
+type Problem struct {
+	Type          string       `json:"type" example:"about:blank"`
+	Title         string       `json:"title" example:"Not Found"`
+	Status        int          `json:"status" example:"404"`
+	Detail        string       `json:"detail,omitempty" example:"book not found"`
+	Instance      string       `json:"instance,omitempty" example:"/api/books/42"`
+	Code          string       `json:"code" example:"book_not_found"`
+	CorrelationID string       `json:"correlation_id,omitempty"`
+	Errors        []FieldError `json:"errors,omitempty"`
+	// BookID is the book already holding an ISBN, on duplicate_isbn.
+	BookID int64 `json:"book_id,omitempty"`
+	// Books is the number of books still in a category, on category_in_use.
+	Books int64 `json:"books,omitempty"`
+}
+
+// CorrelationID returns the correlation id of the request, as set by
+// middleware.CorrelationMiddleware or sent by the client.
+func CorrelationID(c echo.Context) string {
+	if corr, _ := c.Get(CtxCorrKey).(string); corr != "" {
+		return corr
+	}
+	return c.Request().Header.Get("X-Correlation-ID")
+}
@@ -1,18 +1,34 @@
 package utils
 
-import "github.com/go-playground/validator/v10"
+import (
+	"errors"
 
-func FormatValidationErrors(err error) map[string]string {
-	errors := make(map[string]string)
-	if validationErrs, ok := err.(validator.ValidationErrors); ok {
-		for _, fieldErr := range validationErrs {
-			errors[fieldErr.Field()] = getErrorMessage(fieldErr)
-		}
-	} else {
-		// If it's not a validator.ValidationErrors type, return a generic error
-		errors["error"] = err.Error()
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes one field that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// FormatValidationErrors lists the fields rejected by the validator, or
+// returns nil when err does not come from the validator.
+func FormatValidationErrors(err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, FieldError{
+			Field:   fieldErr.Field(),
+			Code:    fieldErr.Tag(),
+			Message: getErrorMessage(fieldErr),
+		})
 	}
-	return errors
+	return fields
 }
 
 func getErrorMessage(fe validator.FieldError) string {
@@ -21,6 +37,8 @@ func getErrorMessage(fe validator.FieldError) string {
 		return "This field is required"
 	case "email":
 		return "Invalid email format"
+	case "isbn":
+		return "Invalid ISBN"
 	case "min":
 		return "Value is too short"
 	case "max":
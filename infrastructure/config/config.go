@@ -2,11 +2,16 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
+	// mysql, postgres or sqlite; sqlite keeps the database in the file
+	// DBName and ignores the other DB settings
+	DBDriver    string
 	DBHost      string
 	DBPort      string
 	DBUser      string
@@ -16,20 +21,69 @@ type Config struct {
 	ElasticURL  string
 	ElasticUser string
 	ElasticPass string
+
+	// search index name; an empty ElasticURL keeps the index in memory
+	ElasticIndex string
+
+	// Open Library compatible ISBN lookup service
+	MetadataURL string
+
+	// cover image storage directory and upload size limit in bytes
+	CoverDir      string
+	CoverMaxBytes int64
+
+	// token lifetimes, e.g. "15m" or "720h"
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// optional admin account bootstrapped at startup
+	AdminUsername string
+	AdminPassword string
+
+	// late-fee rules, amounts in cents
+	FineDailyRate      int64
+	FineGraceDays      int64
+	FineCap            int64
+	FineBlockThreshold int64
 }
 
+var (
+	defaultDBPorts = map[string]string{"mysql": "3306", "postgres": "5432"}
+	defaultDBNames = map[string]string{"mysql": "lms_db", "postgres": "lms_db", "sqlite": "./data/lms.db"}
+)
+
 func LoadConfig() *Config {
 	_ = godotenv.Load()
+	driver := getEnv("DB_DRIVER", "mysql")
 	return &Config{
+		DBDriver:    driver,
 		DBHost:      getEnv("DB_HOST", "localhost"),
-		DBPort:      getEnv("DB_PORT", "3306"),
+		DBPort:      getEnv("DB_PORT", defaultDBPorts[driver]),
 		DBUser:      getEnv("DB_USER", "root"),
 		DBPass:      getEnv("DB_PASS", "p@ssw0rd"),
-		DBName:      getEnv("DB_NAME", "lms_db"),
+		DBName:      getEnv("DB_NAME", defaultDBNames[driver]),
 		JWTSecret:   getEnv("JWT_SECRET", "secret"),
 		ElasticURL:  getEnv("ELASTIC_URL", "http://localhost:9200"),
 		ElasticUser: getEnv("ELASTIC_USER", ""),
 		ElasticPass: getEnv("ELASTIC_PASS", ""),
+
+		ElasticIndex: getEnv("ELASTIC_INDEX", "books"),
+
+		MetadataURL: getEnv("METADATA_URL", "https://openlibrary.org"),
+
+		CoverDir:      getEnv("COVER_DIR", "./data/covers"),
+		CoverMaxBytes: getEnvInt("COVER_MAX_BYTES", 5<<20),
+
+		AccessTokenTTL:  getEnvDuration("JWT_ACCESS_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvDuration("JWT_REFRESH_TTL", 30*24*time.Hour),
+
+		AdminUsername: getEnv("ADMIN_USERNAME", ""),
+		AdminPassword: getEnv("ADMIN_PASSWORD", ""),
+
+		FineDailyRate:      getEnvInt("FINE_DAILY_RATE", 25),
+		FineGraceDays:      getEnvInt("FINE_GRACE_DAYS", 0),
+		FineCap:            getEnvInt("FINE_CAP", 1000),
+		FineBlockThreshold: getEnvInt("FINE_BLOCK_THRESHOLD", 500),
 	}
 }
 
@@ -39,3 +93,21 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int64) int64 {
+	if val, ok := os.LookupEnv(key); ok {
+		if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if val, ok := os.LookupEnv(key); ok {
+		if d, err := time.ParseDuration(val); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
@@ -1,28 +1,58 @@
 package database
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
 
 	"github.com/abushaista/lms-backend/infrastructure/config"
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 func NewGormDB(cfg *config.Config) (*gorm.DB, error) {
-	user := cfg.DBUser
-	pass := cfg.DBPass
-	host := cfg.DBHost
-	port := cfg.DBPort
-	name := cfg.DBName
-
-	if user == "" {
-		log.Fatal("DB env not set")
+	dialector, err := newDialector(cfg)
+	if err != nil {
+		return nil, err
 	}
+	// TranslateError maps duplicate key violations to gorm.ErrDuplicatedKey
+	return gorm.Open(dialector, &gorm.Config{TranslateError: true})
+}
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		user, pass, host, port, name)
-
-	return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+func newDialector(cfg *config.Config) (gorm.Dialector, error) {
+	if cfg.DBDriver == "sqlite" {
+		if dir := filepath.Dir(cfg.DBName); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, err
+			}
+		}
+		// SQLite leaves foreign keys unchecked unless asked, and fails
+		// writes at once while another connection holds the file
+		return sqlite.Open(cfg.DBName + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"), nil
+	}
 
+	if cfg.DBUser == "" {
+		return nil, errors.New("DB_USER is not set")
+	}
+	switch cfg.DBDriver {
+	case "mysql":
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			cfg.DBUser, cfg.DBPass, cfg.DBHost, cfg.DBPort, cfg.DBName)
+		return mysql.Open(dsn), nil
+	case "postgres":
+		dsn := url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(cfg.DBUser, cfg.DBPass),
+			Host:   net.JoinHostPort(cfg.DBHost, cfg.DBPort),
+			Path:   "/" + cfg.DBName,
+		}
+		return postgres.Open(dsn.String()), nil
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q, want mysql, postgres or sqlite", cfg.DBDriver)
+	}
 }
@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"github.com/abushaista/lms-backend/internal/domain"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -9,9 +10,20 @@ type EchoValidator struct {
 }
 
 func NewEchoValidator() *EchoValidator {
-	return &EchoValidator{v: validator.New()}
+	v := validator.New()
+	// replaces the built-in isbn rule, which rejects hyphenated ISBN-13s
+	if err := v.RegisterValidation("isbn", validateISBN); err != nil {
+		panic(err)
+	}
+	return &EchoValidator{v: v}
 }
 
 func (e *EchoValidator) Validate(i interface{}) error {
 	return e.v.Struct(i)
 }
+
+// validateISBN accepts ISBN-10 and ISBN-13 with a valid checksum.
+func validateISBN(fl validator.FieldLevel) bool {
+	_, err := domain.NormalizeISBN(fl.Field().String())
+	return err == nil
+}
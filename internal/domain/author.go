@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+var (
+	ErrAuthorNotFound  = notFound("author_not_found", "author not found")
+	ErrDuplicateAuthor = conflict("duplicate_author", "an author with this name already exists")
+	ErrAuthorInUse     = conflict("author_in_use", "author is credited on books, relink them first")
+)
+
+// Author is a person credited on books. Authors no book credits are deleted
+// for good, so that a name can be reused.
+type Author struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:255;unique;not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type AuthorRepository interface {
+	Save(author *Author) error
+	GetByFilterAll(page, limit int, filter string) ([]*Author, int64, error)
+	GetByID(id uint) (*Author, error)
+	GetByName(name string) (*Author, error)
+	Delete(id uint) error
+}
+
+var authorSeparators = strings.NewReplacer("&", ";", " and ", ";")
+
+// nameSuffixes follow a name after a comma without being a name themselves.
+var nameSuffixes = map[string]bool{"jr": true, "sr": true, "ii": true, "iii": true, "iv": true}
+
+// SplitAuthors splits a byline such as "Ann Lee; Bob Roe & Cy Poe" into
+// author names. Names are separated by semicolons, "&" or "and", and by
+// commas unless the commas are part of a name: "Lee, Ann" is read as Ann Lee
+// and "Bob Roe, Jr." as one name.
+func SplitAuthors(byline string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, group := range strings.Split(authorSeparators.Replace(byline), ";") {
+		for _, name := range splitNameList(group) {
+			if seen[strings.ToLower(name)] {
+				continue
+			}
+			seen[strings.ToLower(name)] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// splitNameList splits a comma separated list of names, or turns a single
+// "Last, First" name around.
+func splitNameList(s string) []string {
+	var parts []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.Join(strings.Fields(p), " "); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	isSuffix := func(p string) bool { return nameSuffixes[strings.ToLower(strings.TrimSuffix(p, "."))] }
+	if len(parts) == 2 && !strings.Contains(parts[0], " ") && !isSuffix(parts[1]) {
+		return []string{parts[1] + " " + parts[0]}
+	}
+	var names []string
+	for _, p := range parts {
+		if isSuffix(p) && len(names) > 0 {
+			names[len(names)-1] += ", " + p
+			continue
+		}
+		names = append(names, p)
+	}
+	return names
+}
+
+// JoinAuthors is the byline of a list of authors. It separates them with
+// semicolons, which SplitAuthors reads back whatever the names look like.
+func JoinAuthors(authors []Author) string {
+	names := make([]string, len(authors))
+	for i, a := range authors {
+		names[i] = a.Name
+	}
+	return strings.Join(names, "; ")
+}
@@ -0,0 +1,46 @@
+package domain_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+)
+
+func TestSplitAuthors(t *testing.T) {
+	tests := []struct {
+		byline string
+		want   []string
+	}{
+		{"Frank Herbert", []string{"Frank Herbert"}},
+		{"Ann Lee; Bob Roe & Cy Poe", []string{"Ann Lee", "Bob Roe", "Cy Poe"}},
+		{"Ann Lee and Bob Roe", []string{"Ann Lee", "Bob Roe"}},
+		{"Ann Lee, Bob Roe, Cy Poe", []string{"Ann Lee", "Bob Roe", "Cy Poe"}},
+		{"Herbert, Frank", []string{"Frank Herbert"}},
+		{"Tolkien, J.R.R.; Lewis, C.S.", []string{"J.R.R. Tolkien", "C.S. Lewis"}},
+		{"Martin Luther King, Jr.", []string{"Martin Luther King, Jr."}},
+		{"King, Jr.", []string{"King, Jr."}},
+		{"Ann Lee, Bob Roe, Jr. & Cy Poe", []string{"Ann Lee", "Bob Roe, Jr.", "Cy Poe"}},
+		{"  Ann   Lee ;; ann lee & ", []string{"Ann Lee"}},
+		{"Anderson Cooper", []string{"Anderson Cooper"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := domain.SplitAuthors(tt.byline); !slices.Equal(got, tt.want) {
+			t.Errorf("SplitAuthors(%q) = %q, want %q", tt.byline, got, tt.want)
+		}
+	}
+}
+
+// Whatever the names look like, a byline made by JoinAuthors splits back
+// into them.
+func TestJoinAuthorsRoundTrip(t *testing.T) {
+	names := []string{"Plato", "Aristotle", "Bob Roe, Jr.", "Ann Lee"}
+	authors := make([]domain.Author, len(names))
+	for i, n := range names {
+		authors[i] = domain.Author{Name: n}
+	}
+	if got := domain.SplitAuthors(domain.JoinAuthors(authors)); !slices.Equal(got, names) {
+		t.Errorf("got %q, want %q", got, names)
+	}
+}
@@ -9,22 +9,46 @@ import (
 type Book struct {
 	ID            int64          `gorm:"primaryKey" json:"id"`
 	Title         string         `json:"title"`
-	Author        string         `json:"author"`
-	ISBN          string         `json:"isbn"`
+	Author        string         `json:"author"` // the byline as catalogued
+	Authors       []Author       `gorm:"many2many:book_authors" json:"authors"`
+	Tags          []Tag          `gorm:"many2many:book_tags" json:"tags"`
+	ISBN          string         `gorm:"size:32" json:"isbn"` // unique among live books
 	Year          int            `json:"year"`
 	CategoryID    uint           `json:"category_id"`
 	Category      Category       `gorm:"foreignKey:CategoryID" json:"category"`
 	Summary       string         `json:"summary"`
-	Available     bool           `gorm:"default:true;not null" json:"available"`
 	CoverImageURL string         `json:"cover_image_url"`
+	CoverImageKey string         `gorm:"size:255" json:"-"`
+	Version       int64          `gorm:"not null;default:1" json:"version"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// computed from the copies of the book, see AfterFind
+	Available       bool  `gorm:"-" json:"available"`
+	TotalCopies     int64 `gorm:"->;-:migration" json:"total_copies"`
+	AvailableCopies int64 `gorm:"->;-:migration" json:"available_copies"`
+}
+
+func (b *Book) AfterFind(tx *gorm.DB) error {
+	b.Available = b.AvailableCopies > 0
+	return nil
 }
 
 type BookRepository interface {
+	// Save also links the book to its Authors, creating those without an id
+	// by name, and to its Tags unless Tags is nil. It fills in the byline
+	// from the authors when Author is empty. An existing book is only
+	// updated when Version is 0 or its current version.
 	Save(b *Book) (int64, error)
-	GetAll(page, limit int, filter BookFilter) ([]*Book, int64, error)
+	GetAll(filter BookFilter, q PageQuery) (*Page[*Book], error)
 	GetByID(id int64) (*Book, error)
-	Delete(id int64) error
+	GetByISBN(isbn string) (*Book, error)
+	// Stream calls fn for every book matching filter, loading batch books at a time.
+	Stream(filter BookFilter, batch int, fn func(b *Book) error) error
+	// SetCover records an uploaded cover: its storage key and public URL.
+	SetCover(id int64, key, url string) error
+	// Delete removes a book when version is 0 or its current version. A book
+	// with open loans or holds is kept, with ErrBookInUse.
+	Delete(id, version int64) error
 }
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCopyNotFound = notFound("copy_not_found", "copy not found")
+	ErrCopyOnLoan   = conflict("copy_on_loan", "copy is on loan")
+	ErrCopyOnHold   = conflict("copy_on_hold", "copy is set aside for a reservation")
+	ErrBarcodeTaken = conflict("barcode_taken", "barcode already in use")
+)
+
+type CopyStatus string
+
+const (
+	CopyAvailable   CopyStatus = "available"
+	CopyOnLoan      CopyStatus = "on_loan"
+	CopyOnHold      CopyStatus = "on_hold"
+	CopyMaintenance CopyStatus = "maintenance"
+	CopyLost        CopyStatus = "lost"
+	CopyWithdrawn   CopyStatus = "withdrawn"
+)
+
+// BookCopy is a physical item of a bibliographic Book record.
+type BookCopy struct {
+	ID            int64          `gorm:"primaryKey" json:"id"`
+	BookID        int64          `gorm:"index;not null" json:"book_id"`
+	Barcode       string         `gorm:"size:64;uniqueIndex;not null" json:"barcode"`
+	ShelfLocation string         `gorm:"size:100" json:"shelf_location"`
+	Condition     string         `gorm:"size:20" json:"condition"`
+	Status        CopyStatus     `gorm:"size:20;index;not null;default:available" json:"status"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// Released fails while the status of the copy is owned by a loan or a
+// reservation.
+func (c *BookCopy) Released() error {
+	switch c.Status {
+	case CopyOnLoan:
+		return ErrCopyOnLoan
+	case CopyOnHold:
+		return ErrCopyOnHold
+	}
+	return nil
+}
+
+type BookCopyRepository interface {
+	// Save updates only the columns a client edits. An empty Status keeps the
+	// stored one, and a new Status fails unless the stored copy is Released;
+	// both are checked against the locked row, so a concurrent checkout or
+	// hold is never overwritten.
+	Save(c *BookCopy) error
+	GetByID(id int64) (*BookCopy, error)
+	GetByBarcode(barcode string) (*BookCopy, error)
+	GetByBook(bookID int64) ([]*BookCopy, error)
+	Delete(id int64) error
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"io"
+	"time"
+)
+
+var (
+	ErrUnsupportedImage = invalid("unsupported_image", "unsupported image type, use jpeg, png or gif")
+	ErrImageTooLarge    = invalid("image_too_large", "image exceeds the size limit")
+	ErrCoverNotFound    = notFound("cover_not_found", "book has no cover image")
+)
+
+// CoverObject is a stored image opened for reading.
+type CoverObject struct {
+	Content     io.ReadSeekCloser
+	ContentType string
+	ModTime     time.Time
+}
+
+// CoverStorage keeps cover images under slash-separated keys.
+type CoverStorage interface {
+	Put(key string, data []byte) error
+	// Open returns ErrCoverNotFound when nothing is stored under key.
+	Open(key string) (*CoverObject, error)
+	Delete(key string) error
+}
@@ -1,9 +1,30 @@
 package domain
 
+import "time"
+
+// BookFilter narrows a list of books; zero fields do not filter. Title,
+// Author and Summary match substrings, ranges include both bounds.
 type BookFilter struct {
-	Title    string
-	Author   string
-	Summary  string
-	Category int
-	Year     int
+	Title   string
+	Author  string
+	Summary string
+	// ISBN matches exactly, in the ISBN-13 form books are stored with.
+	ISBN string
+	// Categories matches books in any of the categories.
+	Categories []uint
+	Year       int
+	YearFrom   int
+	YearTo     int
+	AuthorID   uint
+	TagID      uint
+	// Available, when set, matches books with or without a copy on the shelf.
+	Available *bool
+
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	UpdatedFrom time.Time
+	UpdatedTo   time.Time
+
+	// IncludeSubcategories widens Categories to every category below them
+	IncludeSubcategories bool
 }
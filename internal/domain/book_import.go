@@ -0,0 +1,34 @@
+package domain
+
+var (
+	ErrImportFormat    = invalid("unsupported_import_format", "unsupported import format, use csv or ndjson")
+	ErrMalformedImport = invalid("malformed_import", "malformed import file")
+	ErrImportTooLarge  = invalid("import_too_large", "import exceeds the maximum number of rows")
+	ErrExportFormat    = invalid("unsupported_export_format", "unsupported export format, use csv, ndjson or marcxml")
+)
+
+type ImportStatus string
+
+const (
+	ImportCreated ImportStatus = "created"
+	ImportUpdated ImportStatus = "updated"
+	ImportFailed  ImportStatus = "failed"
+)
+
+// ImportRowResult reports the outcome of one record; Line is its line in the
+// uploaded file.
+type ImportRowResult struct {
+	Line   int          `json:"line"`
+	ISBN   string       `json:"isbn,omitempty"`
+	Status ImportStatus `json:"status"`
+	BookID int64        `json:"book_id,omitempty"`
+	Reason string       `json:"reason,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "errors"
+
+var (
+	ErrMetadataNotFound    = notFound("metadata_not_found", "no metadata found for this ISBN")
+	ErrMetadataUnavailable = errors.New("metadata service unavailable")
+)
+
+// BookMetadata is what a catalogue service knows about an ISBN.
+type BookMetadata struct {
+	ISBN          string `json:"isbn"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	Year          int    `json:"year"`
+	Summary       string `json:"summary"`
+	CoverImageURL string `json:"cover_image_url"`
+}
+
+// MetadataProvider looks up bibliographic data by ISBN. It returns
+// ErrMetadataNotFound when the service does not know the ISBN.
+type MetadataProvider interface {
+	Lookup(isbn string) (*BookMetadata, error)
+}
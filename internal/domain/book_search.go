@@ -0,0 +1,38 @@
+package domain
+
+// BookSearchQuery is a full-text query over the book catalogue. Category and
+// Year narrow the hits without affecting the facet counts.
+type BookSearchQuery struct {
+	Query    string
+	Category uint
+	Year     int
+	Page     int
+	Limit    int
+}
+
+type BookSearchHit struct {
+	BookID     int64               `json:"-"`
+	Book       *Book               `json:"book"`
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+type FacetBucket struct {
+	Key   string `json:"key"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+type BookSearchResult struct {
+	Hits   []BookSearchHit          `json:"data"`
+	Total  int64                    `json:"total"`
+	Facets map[string][]FacetBucket `json:"facets"`
+}
+
+// BookSearchIndex is a relevance-ranked, typo-tolerant index of books kept in
+// sync with the database by BookUseCase.
+type BookSearchIndex interface {
+	Index(b *Book) error
+	Remove(id int64) error
+	Search(q BookSearchQuery) (*BookSearchResult, error)
+}
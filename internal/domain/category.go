@@ -1,24 +1,80 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+var (
+	ErrCategoryNotFound = notFound("category_not_found", "category not found")
+	ErrCategoryCycle    = conflict("category_cycle", "a category cannot be moved under itself or its descendants")
+	ErrCategoryInUse    = conflict("category_in_use", "category still has books")
+	ErrInvalidReassign  = invalid("invalid_reassign", "books can only be reassigned to another existing category")
+)
+
+// CategoryInUseError tells how many books keep a category from being deleted.
+type CategoryInUseError struct {
+	Books int64
+}
+
+func (e *CategoryInUseError) Error() string {
+	return fmt.Sprintf("category still has %d books, reassign or cascade them", e.Books)
+}
+
+func (e *CategoryInUseError) Unwrap() error {
+	return ErrCategoryInUse
+}
+
+// CategoryDeletion says what happens to the books of a deleted category. With
+// neither option set, only a category without books can be deleted.
+type CategoryDeletion struct {
+	// ReassignTo moves the books to another category.
+	ReassignTo uint
+	// Cascade deletes the books together with the category, unless one of
+	// them has open loans or holds.
+	Cascade bool
+	// Version, unless 0, is the version the category must still have.
+	Version int64
+}
+
 type Category struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	Name      string         `gorm:"unique;not null" json:"name"`
+	ParentID  *uint          `gorm:"index" json:"parent_id"`
+	Children  []*Category    `gorm:"foreignKey:ParentID" json:"children,omitempty"`
 	Books     []Book         `json:"books"`
+	Version   int64          `gorm:"not null;default:1" json:"version"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 type CategoryRepository interface {
+	// Save updates an existing category only when Version is 0 or its
+	// current version. ParentID must exist and, as in SetParent, must not be
+	// the category itself or below it.
 	Save(category *Category) error
 	GetAll() ([]*Category, error)
-	GetByFilterAll(page, limit int, filter string) ([]*Category, int64, error)
+	GetByFilterAll(filter string, q PageQuery) (*Page[*Category], error)
 	GetByID(id uint) (*Category, error)
-	Delete(id uint) error
+	GetByName(name string) (*Category, error)
+	// GetDescendantIDs returns id followed by the ids of all categories below
+	// it, or nothing when id does not exist.
+	GetDescendantIDs(id uint) ([]uint, error)
+	// SetParent moves a category under parentID, or to the top level when
+	// parentID is nil. It fails with ErrCategoryCycle when parentID is id or
+	// one of its descendants.
+	SetParent(id uint, parentID *uint) error
+	// Delete removes a category in one transaction, handling its books as d
+	// says and moving its subcategories up to its parent. It returns the ids
+	// of the books that were moved or deleted.
+	Delete(id uint, d CategoryDeletion) ([]int64, error)
+	// Merge moves the books and subcategories of the sources in merges to
+	// their target, deletes the sources and stores merges as the audit
+	// trail, in one transaction. It fills in SourceName and BooksMoved and
+	// returns the ids of the books that were moved.
+	Merge(merges []*CategoryMerge) ([]int64, error)
+	GetMerges(targetID uint) ([]*CategoryMerge, error)
 }
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidMerge = invalid("invalid_merge", "a category cannot be merged into itself")
+
+// CategoryMerge is the audit record of one source category merged into a
+// target. The source is soft-deleted, so its name is kept here.
+type CategoryMerge struct {
+	ID         int64     `gorm:"primaryKey" json:"id"`
+	TargetID   uint      `gorm:"index;not null" json:"target_id"`
+	SourceID   uint      `gorm:"index;not null" json:"source_id"`
+	SourceName string    `gorm:"size:255;not null" json:"source_name"`
+	BooksMoved int64     `gorm:"not null" json:"books_moved"`
+	MergedBy   uuid.UUID `gorm:"type:char(36);not null" json:"merged_by"`
+	Note       string    `gorm:"size:255" json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+}
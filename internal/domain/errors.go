@@ -0,0 +1,72 @@
+package domain
+
+import "errors"
+
+// Error kinds. Every error of the domain wraps one of them, so callers such
+// as the HTTP layer can react to an error without knowing it.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// kindCodes are the codes of errors that wrap a kind without being a domain
+// error themselves, such as constraint violations reported by the database.
+var kindCodes = map[error]string{
+	ErrNotFound:     "not_found",
+	ErrConflict:     "conflict",
+	ErrValidation:   "validation_failed",
+	ErrForbidden:    "forbidden",
+	ErrUnauthorized: "unauthorized",
+}
+
+// kindError is a domain error of one of the kinds above. Its code is stable
+// and meant for clients to branch on; the message may change.
+type kindError struct {
+	code string
+	msg  string
+	kind error
+}
+
+func (e *kindError) Error() string { return e.msg }
+func (e *kindError) Unwrap() error { return e.kind }
+
+func notFound(code, msg string) error {
+	return &kindError{code: code, msg: msg, kind: ErrNotFound}
+}
+
+func conflict(code, msg string) error {
+	return &kindError{code: code, msg: msg, kind: ErrConflict}
+}
+
+func invalid(code, msg string) error {
+	return &kindError{code: code, msg: msg, kind: ErrValidation}
+}
+
+func forbidden(code, msg string) error {
+	return &kindError{code: code, msg: msg, kind: ErrForbidden}
+}
+
+func unauthorized(code, msg string) error {
+	return &kindError{code: code, msg: msg, kind: ErrUnauthorized}
+}
+
+// Describe returns the code of err and a message that is safe to show to
+// clients. The message of a domain error is err itself, including what
+// wraps it; an error that merely wraps a kind is described by the kind
+// alone, since the rest may come from the database. ok is false for errors
+// that are not of any kind.
+func Describe(err error) (code, message string, ok bool) {
+	var ke *kindError
+	if errors.As(err, &ke) {
+		return ke.code, err.Error(), true
+	}
+	for kind, code := range kindCodes {
+		if errors.Is(err, kind) {
+			return code, kind.Error(), true
+		}
+	}
+	return "", "", false
+}
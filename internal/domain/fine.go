@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFineNotFound        = notFound("fine_not_found", "fine not found")
+	ErrFineSettled         = conflict("fine_settled", "fine is already settled")
+	ErrOverpayment         = conflict("overpayment", "amount exceeds the outstanding fine")
+	ErrFineBalanceExceeded = forbidden("fine_balance_exceeded", "outstanding fines exceed the borrowing limit")
+)
+
+// FinePolicy holds the late-fee rules. Amounts are in cents.
+type FinePolicy struct {
+	DailyRate      int64
+	GraceDays      int64
+	Cap            int64 // 0 means uncapped
+	BlockThreshold int64
+}
+
+// Assess returns the fee for a loan due at dueAt and returned at returnedAt.
+// Days inside the grace period are free; every started day after it is charged.
+func (p FinePolicy) Assess(dueAt, returnedAt time.Time) int64 {
+	late := returnedAt.Sub(dueAt)
+	if late <= 0 {
+		return 0
+	}
+	day := 24 * time.Hour
+	days := int64((late + day - 1) / day)
+	if days <= p.GraceDays {
+		return 0
+	}
+	amount := (days - p.GraceDays) * p.DailyRate
+	if p.Cap > 0 && amount > p.Cap {
+		amount = p.Cap
+	}
+	return amount
+}
+
+// Fine is the late fee charged to a user for one loan. Amounts are in cents.
+type Fine struct {
+	ID           int64             `gorm:"primaryKey" json:"id"`
+	UserID       uuid.UUID         `gorm:"type:char(36);index;not null" json:"user_id"`
+	LoanID       int64             `gorm:"uniqueIndex;not null" json:"loan_id"`
+	Amount       int64             `gorm:"not null" json:"amount"`
+	Paid         int64             `gorm:"not null;default:0" json:"paid"`
+	Waived       int64             `gorm:"not null;default:0" json:"waived"`
+	Reason       string            `gorm:"size:255" json:"reason"`
+	Transactions []FineTransaction `gorm:"foreignKey:FineID" json:"transactions"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt    `gorm:"index" json:"-"`
+
+	// computed, see AfterFind
+	Outstanding int64 `gorm:"-" json:"outstanding"`
+}
+
+func (f *Fine) AfterFind(tx *gorm.DB) error {
+	f.Outstanding = f.Amount - f.Paid - f.Waived
+	return nil
+}
+
+type FineTransactionKind string
+
+const (
+	FinePayment FineTransactionKind = "payment"
+	FineWaiver  FineTransactionKind = "waiver"
+)
+
+// FineTransaction is a ledger entry reducing the outstanding amount of a fine.
+type FineTransaction struct {
+	ID        int64               `gorm:"primaryKey" json:"id"`
+	FineID    int64               `gorm:"index;not null" json:"fine_id"`
+	UserID    uuid.UUID           `gorm:"type:char(36);index;not null" json:"user_id"`
+	Kind      FineTransactionKind `gorm:"size:20;not null" json:"kind"`
+	Amount    int64               `gorm:"not null" json:"amount"`
+	Note      string              `gorm:"size:255" json:"note"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+// FineRepository keeps the fines, which are charged by
+// LoanRepository.Return along with the return.
+type FineRepository interface {
+	GetByID(id int64) (*Fine, error)
+	GetByUser(userID uuid.UUID) ([]*Fine, error)
+	OutstandingBalance(userID uuid.UUID) (int64, error)
+	// Apply records the transaction and reduces the fine atomically. It
+	// returns ErrOverpayment when the amount exceeds what is outstanding.
+	Apply(t *FineTransaction) (*Fine, error)
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidISBN   = invalid("invalid_isbn", "invalid ISBN")
+	ErrDuplicateISBN = conflict("duplicate_isbn", "a book with this ISBN already exists")
+)
+
+// DuplicateISBNError carries the book that already holds an ISBN.
+type DuplicateISBNError struct {
+	ISBN   string
+	BookID int64
+}
+
+func (e *DuplicateISBNError) Error() string {
+	return fmt.Sprintf("ISBN %s is already used by book %d", e.ISBN, e.BookID)
+}
+
+func (e *DuplicateISBNError) Unwrap() error {
+	return ErrDuplicateISBN
+}
+
+// NormalizeISBN checks the checksum of an ISBN-10 or ISBN-13, ignoring
+// hyphens and spaces, and returns it as a bare ISBN-13.
+func NormalizeISBN(raw string) (string, error) {
+	s := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(raw))
+	switch len(s) {
+	case 10:
+		if !validISBN10(s) {
+			return "", ErrInvalidISBN
+		}
+		isbn := "978" + s[:9]
+		return isbn + string(isbn13CheckDigit(isbn)), nil
+	case 13:
+		if !isDigits(s) || (!strings.HasPrefix(s, "978") && !strings.HasPrefix(s, "979")) ||
+			isbn13CheckDigit(s[:12]) != s[12] {
+			return "", ErrInvalidISBN
+		}
+		return s, nil
+	default:
+		return "", ErrInvalidISBN
+	}
+}
+
+func validISBN10(s string) bool {
+	if !isDigits(s[:9]) {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(s[i]-'0') * (10 - i)
+	}
+	switch c := s[9]; {
+	case c == 'X':
+		sum += 10
+	case c >= '0' && c <= '9':
+		sum += int(c - '0')
+	default:
+		return false
+	}
+	return sum%11 == 0
+}
+
+// isbn13CheckDigit computes the check digit of the first 12 digits.
+func isbn13CheckDigit(s string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(s[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
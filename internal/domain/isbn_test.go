@@ -0,0 +1,44 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string // empty when the ISBN is invalid
+	}{
+		{"9780306406157", "9780306406157"},
+		{"978-0-306-40615-7", "9780306406157"},
+		{" 978 0 306 40615 7 ", "9780306406157"},
+		{"9791098765438", "9791098765438"},
+		{"0306406152", "9780306406157"},
+		{"0-306-40615-2", "9780306406157"},
+		{"080442957X", "9780804429573"},
+		{"080442957x", "9780804429573"},
+		{"9780306406158", ""},  // wrong check digit
+		{"0306406153", ""},     // wrong check digit
+		{"9770306406155", ""},  // neither 978 nor 979
+		{"97803064061X7", ""},  // X only ends an ISBN-10
+		{"X306406152", ""},     // X only as the check digit
+		{"978030640615", ""},   // too short
+		{"97803064061570", ""}, // too long
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got, err := domain.NormalizeISBN(tt.raw)
+		if tt.want == "" {
+			if !errors.Is(err, domain.ErrInvalidISBN) {
+				t.Errorf("NormalizeISBN(%q) = %q, %v; want %v", tt.raw, got, err, domain.ErrInvalidISBN)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeISBN(%q) = %q, %v; want %q", tt.raw, got, err, tt.want)
+		}
+	}
+}
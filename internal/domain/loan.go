@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBookNotFound      = notFound("book_not_found", "book not found")
+	ErrBookUnavailable   = conflict("book_unavailable", "no copy of the book is available")
+	ErrBookInUse         = conflict("book_in_use", "book has open loans or holds, close them first")
+	ErrLoanNotFound      = notFound("loan_not_found", "loan not found")
+	ErrLoanReturned      = conflict("loan_returned", "loan already returned")
+	ErrLoanNotOwned      = forbidden("loan_not_owned", "loan belongs to another user")
+	ErrLoanOverdue       = conflict("loan_overdue", "overdue loans cannot be renewed")
+	ErrRenewLimitReached = conflict("renew_limit_reached", "renewal limit reached")
+	ErrHoldsWaiting      = conflict("holds_waiting", "other members are waiting for the book")
+	ErrLoanChanged       = conflict("loan_changed", "loan was changed by another request, try again")
+)
+
+// Loan ties a user to a checked-out copy of a book. A loan is open until ReturnedAt is set.
+type Loan struct {
+	ID         int64          `gorm:"primaryKey" json:"id"`
+	UserID     uuid.UUID      `gorm:"type:char(36);index;not null" json:"user_id"`
+	BookID     int64          `gorm:"index;not null" json:"book_id"`
+	Book       Book           `gorm:"foreignKey:BookID" json:"book"`
+	CopyID     int64          `gorm:"index" json:"copy_id"`
+	BorrowedAt time.Time      `gorm:"not null" json:"borrowed_at"`
+	DueAt      time.Time      `gorm:"index;not null" json:"due_at"`
+	ReturnedAt *time.Time     `gorm:"index" json:"returned_at"`
+	Renewals   int            `gorm:"not null;default:0" json:"renewals"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (l *Loan) IsOpen() bool {
+	return l.ReturnedAt == nil
+}
+
+func (l *Loan) IsOverdue(now time.Time) bool {
+	return l.IsOpen() && now.After(l.DueAt)
+}
+
+type LoanRepository interface {
+	// Checkout picks the copy held for the borrower or else an available copy
+	// of the book, marks it on loan and creates the loan atomically. It
+	// returns ErrBookUnavailable when every copy of the book is taken.
+	Checkout(l *Loan) error
+	// Return closes the loan, charges fine unless it is nil, and hands the
+	// copy to the next waiting hold or puts it back on the shelf atomically.
+	Return(l *Loan, fine *Fine) error
+	// Renew moves the due date of an open loan to dueAt and counts the
+	// renewal atomically, unless the loan was renewed since l was read
+	// (ErrLoanChanged), was returned (ErrLoanReturned) or someone waits for
+	// the book (ErrHoldsWaiting).
+	Renew(l *Loan, dueAt time.Time) error
+	GetByID(id int64) (*Loan, error)
+	GetByUser(userID uuid.UUID, page, limit int) ([]*Loan, int64, error)
+	GetOverdue(now time.Time, page, limit int) ([]*Loan, int64, error)
+	// GetOverdueByUser returns the open loans of the user due before now.
+	GetOverdueByUser(userID uuid.UUID, now time.Time) ([]*Loan, error)
+}
@@ -0,0 +1,50 @@
+package domain
+
+// ErrInvalidSort is returned for sorting by a field that is not a sort field.
+var ErrInvalidSort = invalid("invalid_sort", "unknown sort field")
+
+// ErrInvalidCursor is returned for a cursor that was not issued for the list
+// it is sent to, for instance after the sort order was changed.
+var ErrInvalidCursor = invalid("invalid_cursor", "the cursor does not belong to this list")
+
+// Sort orders a list by one of its sort fields, in descending order when
+// Desc is set.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Sort fields lists may be ordered by. Ties are always broken by id, so that
+// every order is total and cursors stay stable. Only columns that are never
+// NULL are listed.
+var (
+	BookSortFields     = []string{"id", "title", "author", "year", "isbn"}
+	CategorySortFields = []string{"id", "name"}
+)
+
+// Cursor marks a position in a sorted list by the sort values, id last, of
+// the item at the edge of a page. The page it asks for starts just after that
+// item, or ends just before it with Before.
+type Cursor struct {
+	Values []any `json:"v"`
+	Before bool  `json:"b,omitempty"`
+}
+
+// PageQuery selects one page of a list, by Cursor when it is set and by the
+// 1-based Page number otherwise.
+type PageQuery struct {
+	Page   int
+	Limit  int
+	Sort   []Sort
+	Cursor *Cursor
+}
+
+// Page is one page of a list. NextCursor and PrevCursor are nil at either end
+// of the list; pages selected by number get them too, so that clients can
+// move on with cursors.
+type Page[T any] struct {
+	Items      []T
+	Total      int64
+	NextCursor *Cursor
+	PrevCursor *Cursor
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRefreshToken = unauthorized("invalid_refresh_token", "invalid or expired refresh token")
+	ErrRefreshTokenReused  = unauthorized("refresh_token_reused", "refresh token reuse detected, session revoked")
+	ErrTokenRevoked        = unauthorized("token_revoked", "token has been revoked")
+)
+
+// RefreshToken is a single-use token of a login session. Every refresh rotates
+// it into a new token of the same family; presenting a rotated token again
+// revokes the whole family.
+type RefreshToken struct {
+	ID        uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:char(36);index;not null" json:"user_id"`
+	FamilyID  uuid.UUID  `gorm:"type:char(36);index;not null" json:"family_id"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TokenPair is returned by login and refresh.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type RefreshTokenRepository interface {
+	Create(t *RefreshToken) error
+	GetByHash(hash string) (*RefreshToken, error)
+	// MarkUsed flags the token as rotated. It returns ErrRefreshTokenReused
+	// when the token was already used, so concurrent refreshes cannot both win.
+	MarkUsed(id uuid.UUID, at time.Time) error
+	RevokeFamily(familyID uuid.UUID, at time.Time) error
+	IsFamilyRevoked(familyID uuid.UUID) (bool, error)
+}
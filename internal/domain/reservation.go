@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReservationNotFound = notFound("reservation_not_found", "reservation not found")
+	ErrReservationNotOwned = forbidden("reservation_not_owned", "reservation belongs to another user")
+	ErrReservationClosed   = conflict("reservation_closed", "reservation is no longer active")
+	ErrBookAvailable       = conflict("book_available", "book is available, check it out instead")
+	ErrDuplicateHold       = conflict("duplicate_hold", "book is already on hold for this user")
+)
+
+// HoldPickupWindow is how long a copy set aside for a hold waits for its
+// borrower.
+const HoldPickupWindow = 3 * 24 * time.Hour
+
+type ReservationStatus string
+
+const (
+	ReservationWaiting   ReservationStatus = "waiting"
+	ReservationReady     ReservationStatus = "ready"
+	ReservationFulfilled ReservationStatus = "fulfilled"
+	ReservationCancelled ReservationStatus = "cancelled"
+	ReservationExpired   ReservationStatus = "expired"
+)
+
+// Reservation is a hold of a user on a book. Waiting holds form a FIFO queue
+// per book; the head of the queue becomes ready when a copy is set aside for it.
+type Reservation struct {
+	ID        int64             `gorm:"primaryKey" json:"id"`
+	UserID    uuid.UUID         `gorm:"type:char(36);index;not null" json:"user_id"`
+	BookID    int64             `gorm:"index;not null" json:"book_id"`
+	Book      Book              `gorm:"foreignKey:BookID" json:"book"`
+	CopyID    int64             `gorm:"index" json:"copy_id,omitempty"`
+	Status    ReservationStatus `gorm:"size:20;index;not null" json:"status"`
+	Position  int64             `gorm:"-" json:"position,omitempty"`
+	ReadyAt   *time.Time        `json:"ready_at"`
+	ExpiresAt *time.Time        `gorm:"index" json:"expires_at"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	DeletedAt gorm.DeletedAt    `gorm:"index" json:"-"`
+}
+
+func (r *Reservation) IsActive() bool {
+	return r.Status == ReservationWaiting || r.Status == ReservationReady
+}
+
+type ReservationRepository interface {
+	// Place queues a waiting hold atomically, unless a copy of the book is
+	// available (ErrBookAvailable) or the user already holds the book
+	// (ErrDuplicateHold).
+	Place(r *Reservation) error
+	GetByID(id int64) (*Reservation, error)
+	GetByUser(userID uuid.UUID) ([]*Reservation, error)
+	GetQueue(bookID int64) ([]*Reservation, error)
+	GetExpired(now time.Time) ([]*Reservation, error)
+	// CountAhead returns the number of waiting holds queued before r.
+	CountAhead(r *Reservation) (int64, error)
+	// PromoteNext sets an available copy aside for the oldest waiting hold of
+	// the book atomically. It returns nil when there is no copy or no hold.
+	PromoteNext(bookID int64, readyAt, expiresAt time.Time) (*Reservation, error)
+	// Close moves an active hold to the given status and hands its copy to
+	// the next waiting hold or puts it back on the shelf atomically.
+	Close(r *Reservation, status ReservationStatus) error
+}
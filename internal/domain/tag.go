@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+var (
+	ErrTagNotFound  = notFound("tag_not_found", "tag not found")
+	ErrDuplicateTag = conflict("duplicate_tag", "a tag with this name already exists")
+)
+
+// Tag is a free-form label on books. Like authors, tags are deleted for
+// good together with their links to books.
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:100;unique;not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TagRepository interface {
+	Save(tag *Tag) error
+	GetByFilterAll(page, limit int, filter string) ([]*Tag, int64, error)
+	GetByID(id uint) (*Tag, error)
+	Delete(id uint) error
+}
@@ -7,10 +7,25 @@ import (
 	"gorm.io/gorm"
 )
 
+var (
+	ErrUserNotFound       = notFound("user_not_found", "user not found")
+	ErrUsernameTaken      = conflict("username_taken", "username already exists")
+	ErrInvalidCredentials = unauthorized("invalid_credentials", "invalid username or password")
+)
+
+type Role string
+
+const (
+	RoleAdmin     Role = "admin"
+	RoleLibrarian Role = "librarian"
+	RoleMember    Role = "member"
+)
+
 type User struct {
 	ID        uuid.UUID      `gorm:"type:char(36);primaryKey" json:"id"`
 	Username  string         `gorm:"size:100;uniqueIndex;not null" json:"username" validate:"required,min=3,max=100"`
 	Password  string         `gorm:"size:255;not null" json:"-"`
+	Role      Role           `gorm:"size:20;not null;default:member" json:"role"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -19,4 +34,6 @@ type User struct {
 type UserRepository interface {
 	CreateUser(u *User) (string, error)
 	GetByUsername(username string) (*User, error)
+	GetByID(id uuid.UUID) (*User, error)
+	UpdateRole(id uuid.UUID, role Role) error
 }
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// UserContext is the authenticated caller, built from the validated JWT claims.
+type UserContext struct {
+	UserID    uuid.UUID
+	Username  string
+	Role      Role
+	SessionID string
+}
+
+type userContextKey struct{}
+
+// WithUser returns a copy of ctx carrying the caller.
+func WithUser(ctx context.Context, user *UserContext) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// UserFrom returns the caller carried by ctx, for use cases that act on
+// behalf of the authenticated user without depending on echo.
+func UserFrom(ctx context.Context) (*UserContext, bool) {
+	user, ok := ctx.Value(userContextKey{}).(*UserContext)
+	return user, ok && user != nil
+}
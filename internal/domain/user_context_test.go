@@ -0,0 +1,23 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestUserFrom(t *testing.T) {
+	if _, ok := domain.UserFrom(context.Background()); ok {
+		t.Error("got a user from an empty context")
+	}
+	if _, ok := domain.UserFrom(domain.WithUser(context.Background(), nil)); ok {
+		t.Error("got a user from a context carrying nil")
+	}
+	user := &domain.UserContext{UserID: uuid.New(), Username: "ada", Role: domain.RoleLibrarian}
+	got, ok := domain.UserFrom(domain.WithUser(context.Background(), user))
+	if !ok || got != user {
+		t.Errorf("got %v, %v; want the user stored", got, ok)
+	}
+}
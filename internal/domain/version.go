@@ -0,0 +1,7 @@
+package domain
+
+// ErrVersionMismatch is returned by writes that expect a record to still have
+// the version it had when it was read. Versioned records, books and
+// categories, increment their Version on every change; a write expecting
+// version 0 does not check it.
+var ErrVersionMismatch = conflict("version_mismatch", "the record was changed since it was read")
@@ -9,3 +9,11 @@ type LoginRequest struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
 }
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=admin librarian member"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
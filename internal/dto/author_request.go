@@ -0,0 +1,6 @@
+package dto
+
+type AuthorRequest struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name" validate:"required,max=255"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+type BookCopyRequest struct {
+	ID            int64  `json:"id"`
+	BookID        int64  `json:"book_id"`
+	Barcode       string `json:"barcode" validate:"required,max=64"`
+	ShelfLocation string `json:"shelf_location" validate:"max=100"`
+	Condition     string `json:"condition" validate:"omitempty,oneof=new good fair poor damaged"`
+	Status        string `json:"status" validate:"omitempty,oneof=available maintenance lost withdrawn"`
+}
@@ -0,0 +1,19 @@
+package dto
+
+// BookImportRow is one record of a bulk import. The category is given by name
+// or by id; the remaining fields follow CreateBookRequest.
+type BookImportRow struct {
+	Title      string `json:"title"`
+	Author     string `json:"author"`
+	ISBN       string `json:"isbn"`
+	Year       int    `json:"year"`
+	Summary    string `json:"summary"`
+	CoverImage string `json:"cover_image"`
+	Category   string `json:"category"`
+	CategoryID uint   `json:"category_id"`
+}
+
+type BookImportOptions struct {
+	DryRun           bool
+	CreateCategories bool
+}
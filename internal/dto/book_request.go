@@ -2,22 +2,33 @@ package dto
 
 type CreateBookRequest struct {
 	Title      string `json:"title" validate:"required"`
-	Author     string `json:"author" validate:"required"`
-	ISBN       string `json:"isbn" validate:"required"`
+	Author     string `json:"author" validate:"required_without=AuthorIDs"`
+	ISBN       string `json:"isbn" validate:"required,isbn"`
 	Year       int    `json:"year" validate:"required"`
 	Summary    string `json:"summary" validate:"required"`
-	CoverImage string `json:"cover_image" validate:"omitempty,url"`
+	CoverImage string `json:"cover_image" validate:"omitempty,uri"` // or the path of an uploaded cover
 	CategoryID uint   `json:"category_id" validate:"required"`
-	Available  bool   `json:"available" validate:"required"`
+	// AuthorIDs links existing authors; without them the authors are taken
+	// from the byline in Author.
+	AuthorIDs []uint `json:"author_ids" validate:"omitempty,dive,required"`
+	TagIDs    []uint `json:"tag_ids" validate:"omitempty,dive,required"`
 }
 
 type UpdateBookRequest struct {
 	ID         int64  `json:"id"`
 	Title      string `json:"title" validate:"required"`
-	Author     string `json:"author" validate:"required"`
-	ISBN       string `json:"isbn" validate:"required"`
+	Author     string `json:"author" validate:"required_without=AuthorIDs"`
+	ISBN       string `json:"isbn" validate:"required,isbn"`
 	Year       int    `json:"year" validate:"required"`
 	Summary    string `json:"summary" validate:"required"`
-	CoverImage string `json:"cover_image" validate:"omitempty,url"`
+	CoverImage string `json:"cover_image" validate:"omitempty,uri"` // or the path of an uploaded cover
 	CategoryID uint   `json:"category_id" validate:"required"`
+	// AuthorIDs links existing authors; without them the authors are taken
+	// from the byline in Author.
+	AuthorIDs []uint `json:"author_ids" validate:"omitempty,dive,required"`
+	// TagIDs replaces the tags of the book; null leaves them as they are.
+	TagIDs []uint `json:"tag_ids" validate:"omitempty,dive,required"`
+	// Version is the version the book must still have, 0 for any; it comes
+	// from the If-Match header.
+	Version int64 `json:"-"`
 }
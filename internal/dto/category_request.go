@@ -1,6 +1,22 @@
 package dto
 
 type CategoryRequest struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name" validate:"required"`
+	ID       uint   `json:"id"`
+	Name     string `json:"name" validate:"required"`
+	ParentID *uint  `json:"parent_id"`
+	// Version is the version the category must still have, 0 for any; it
+	// comes from the If-Match header.
+	Version int64 `json:"-"`
+}
+
+// MoveCategoryRequest places a category under another one, or at the top
+// level when ParentID is null.
+type MoveCategoryRequest struct {
+	ParentID *uint `json:"parent_id"`
+}
+
+// MergeCategoriesRequest merges the source categories into the target one.
+type MergeCategoriesRequest struct {
+	SourceIDs []uint `json:"source_ids" validate:"required,min=1,dive,required"`
+	Note      string `json:"note" validate:"max=255"`
 }
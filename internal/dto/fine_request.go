@@ -0,0 +1,13 @@
+package dto
+
+// FinePaymentRequest pays part or all of a fine. Amount is in cents.
+type FinePaymentRequest struct {
+	Amount int64  `json:"amount" validate:"required,gt=0"`
+	Note   string `json:"note" validate:"max=255"`
+}
+
+// FineWaiverRequest forgives part of a fine, or all of it when Amount is 0.
+type FineWaiverRequest struct {
+	Amount int64  `json:"amount" validate:"gte=0"`
+	Note   string `json:"note" validate:"required,max=255"`
+}
@@ -0,0 +1,5 @@
+package dto
+
+type ReservationRequest struct {
+	BookID int64 `json:"book_id" validate:"required"`
+}
@@ -0,0 +1,6 @@
+package dto
+
+type TagRequest struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name" validate:"required,max=100"`
+}
@@ -0,0 +1,665 @@
+package repository_test
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// repositories are the repositories of one implementation, sharing their
+// data.
+type repositories struct {
+	books      domain.BookRepository
+	categories domain.CategoryRepository
+	users      domain.UserRepository
+	tags       domain.TagRepository
+	copies     domain.BookCopyRepository
+}
+
+// implementations open empty repositories of every implementation the
+// contract tests run against.
+var implementations = []struct {
+	name string
+	open func(t *testing.T) repositories
+}{
+	{"memory", func(t *testing.T) repositories {
+		s := repository.NewMemoryStore()
+		return repositories{
+			books:      repository.NewMemoryBookRepository(s),
+			categories: repository.NewMemoryCategoryRepository(s),
+			users:      repository.NewMemoryUserRepository(s),
+			tags:       repository.NewMemoryTagRepository(s),
+			copies:     repository.NewMemoryBookCopyRepository(s),
+		}
+	}},
+	{"gorm", func(t *testing.T) repositories {
+		db := newTestDB(t)
+		return repositories{
+			books:      repository.NewGormBookRepository(db),
+			categories: repository.NewGormCategoryRepository(db),
+			users:      repository.NewGormUserRepository(db),
+			tags:       repository.NewGormTagRepository(db),
+			copies:     repository.NewGormBookCopyRepository(db),
+		}
+	}},
+}
+
+// runContract runs test against every implementation.
+func runContract(t *testing.T, test func(t *testing.T, r repositories)) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			test(t, impl.open(t))
+		})
+	}
+}
+
+// seedContract saves, through the repositories, Fiction (1) with SF (2) below
+// it and History (3), and five books, two of them titled Foundation.
+func seedContract(t *testing.T, r repositories) {
+	t.Helper()
+	fiction := uint(1)
+	categories := []*domain.Category{
+		{Name: "Fiction"},
+		{Name: "SF", ParentID: &fiction},
+		{Name: "History"},
+	}
+	for _, c := range categories {
+		if err := r.categories.Save(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	books := []*domain.Book{
+		{Title: "Dune", Authors: []domain.Author{{Name: "Frank Herbert"}}, ISBN: "9780306406157", Year: 1965,
+			CategoryID: 2, Summary: "a desert planet", CreatedAt: date("2024-01-10")},
+		{Title: "Foundation", Authors: []domain.Author{{Name: "Isaac Asimov"}}, ISBN: "9780198526636", Year: 1951,
+			CategoryID: 2, Summary: "a galactic empire falls", CreatedAt: date("2024-02-05")},
+		{Title: "The Hobbit", Authors: []domain.Author{{Name: "J.R.R. Tolkien"}}, ISBN: "9780131103627", Year: 1937,
+			CategoryID: 1, Summary: "there and back again", CreatedAt: date("2024-03-15")},
+		{Title: "SPQR", Authors: []domain.Author{{Name: "Mary Beard"}}, ISBN: "9780262033848", Year: 2015,
+			CategoryID: 3, Summary: "a history of ancient Rome", CreatedAt: date("2024-05-20")},
+		{Title: "Foundation", Authors: []domain.Author{{Name: "Isaac Asimov"}}, ISBN: "9780553293357", Year: 2004,
+			CategoryID: 2, Summary: "a reissue", CreatedAt: date("2024-06-01")},
+	}
+	for _, b := range books {
+		if _, err := r.books.Save(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func bookIDs(books []*domain.Book) []int64 {
+	var ids []int64
+	for _, b := range books {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+// sendCursor passes a cursor through JSON, as it is when sent to clients.
+func sendCursor(t *testing.T, c *domain.Cursor) *domain.Cursor {
+	t.Helper()
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got domain.Cursor
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	return &got
+}
+
+func TestBookRepositorySave(t *testing.T) {
+	runContract(t, func(t *testing.T, r repositories) {
+		seedContract(t, r)
+
+		b := &domain.Book{Title: "Children of Time", Authors: []domain.Author{{Name: "Adrian Tchaikovsky"}},
+			ISBN: "9781447273301", Year: 2015, CategoryID: 2}
+		id, err := r.books.Save(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != 6 || b.Version != 1 || b.Author != "Adrian Tchaikovsky" {
+			t.Errorf("saved book %d version %d by %q, want 6, 1 and the byline of its authors", id, b.Version, b.Author)
+		}
+		got, err := r.books.GetByID(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Category.Name != "SF" || len(got.Authors) != 1 || got.Authors[0].Name != "Adrian Tchaikovsky" {
+			t.Errorf("got category %q and authors %v, want SF and Adrian Tchaikovsky", got.Category.Name, got.Authors)
+		}
+		if got, err := r.books.GetByISBN("9781447273301"); err != nil || got.ID != id {
+			t.Errorf("GetByISBN: got %v, %v", got, err)
+		}
+
+		got.Year = 2016
+		got.Version = 1
+		if _, err := r.books.Save(got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Version != 2 {
+			t.Errorf("got version %d after an update, want 2", got.Version)
+		}
+		got.Version = 1
+		if _, err := r.books.Save(got); !errors.Is(err, domain.ErrVersionMismatch) {
+			t.Errorf("saving a stale version: got %v, want %v", err, domain.ErrVersionMismatch)
+		}
+		if err := r.books.SetCover(id, "covers/6.jpg", "/covers/6.jpg"); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := r.books.GetByID(id); got.Version != 3 || got.CoverImageKey != "covers/6.jpg" {
+			t.Errorf("got version %d and cover %q after SetCover, want 3 and covers/6.jpg", got.Version, got.CoverImageKey)
+		}
+
+		errorTests := []struct {
+			name string
+			book *domain.Book
+			want error
+		}{
+			{"duplicate isbn", &domain.Book{Title: "Dune", Author: "Frank Herbert", ISBN: "9780306406157", CategoryID: 2}, domain.ErrDuplicateISBN},
+			{"unknown book", &domain.Book{ID: 99, Title: "Dune", Author: "Frank Herbert", ISBN: "9780000000002", CategoryID: 2}, domain.ErrBookNotFound},
+			{"unknown author", &domain.Book{Title: "Dune", Authors: []domain.Author{{ID: 99}}, ISBN: "9780000000002", CategoryID: 2}, domain.ErrAuthorNotFound},
+			{"unknown tag", &domain.Book{Title: "Dune", Author: "Frank Herbert", Tags: []domain.Tag{{ID: 99}}, ISBN: "9780000000002", CategoryID: 2}, domain.ErrTagNotFound},
+		}
+		for _, tt := range errorTests {
+			if _, err := r.books.Save(tt.book); !errors.Is(err, tt.want) {
+				t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+			}
+		}
+	})
+}
+
+func TestBookRepositoryDelete(t *testing.T) {
+	runContract(t, func(t *testing.T, r repositories) {
+		seedContract(t, r)
+
+		if err := r.books.Delete(1, 2); !errors.Is(err, domain.ErrVersionMismatch) {
+			t.Errorf("deleting a stale version: got %v, want %v", err, domain.ErrVersionMismatch)
+		}
+		if err := r.books.Delete(1, 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.books.GetByID(1); !errors.Is(err, domain.ErrBookNotFound) {
+			t.Errorf("getting a deleted book: got %v, want %v", err, domain.ErrBookNotFound)
+		}
+		if err := r.books.Delete(1, 0); !errors.Is(err, domain.ErrBookNotFound) {
+			t.Errorf("deleting a deleted book: got %v, want %v", err, domain.ErrBookNotFound)
+		}
+		// the ISBN of a deleted book is free again
+		b := &domain.Book{Title: "Dune", Author: "Frank Herbert", ISBN: "9780306406157", Year: 1965, CategoryID: 2}
+		if _, err := r.books.Save(b); err != nil {
+			t.Errorf("reusing the ISBN of a deleted book: %v", err)
+		}
+	})
+}
+
+func TestBookRepositoryGetAll(t *testing.T) {
+	no := false
+	tests := []struct {
+		name   string
+		filter domain.BookFilter
+		want   []int64
+	}{
+		{"no filter", domain.BookFilter{}, []int64{1, 2, 3, 4, 5}},
+		{"title substring", domain.BookFilter{Title: "o"}, []int64{2, 3, 5}},
+		{"author substring", domain.BookFilter{Author: "asimov"}, []int64{2, 5}},
+		{"summary substring", domain.BookFilter{Summary: "History"}, []int64{4}},
+		{"isbn", domain.BookFilter{ISBN: "9780131103627"}, []int64{3}},
+		{"author id", domain.BookFilter{AuthorID: 2}, []int64{2, 5}},
+		{"several categories", domain.BookFilter{Categories: []uint{1, 3}}, []int64{3, 4}},
+		{"category with subcategories", domain.BookFilter{Categories: []uint{1}, IncludeSubcategories: true}, []int64{1, 2, 3, 5}},
+		{"unknown category with subcategories", domain.BookFilter{Categories: []uint{99}, IncludeSubcategories: true}, nil},
+		{"year range", domain.BookFilter{YearFrom: 1950, YearTo: 2004}, []int64{1, 2, 5}},
+		{"not available", domain.BookFilter{Available: &no}, []int64{1, 2, 3, 4, 5}},
+		{"created range", domain.BookFilter{CreatedFrom: date("2024-02-05"), CreatedTo: date("2024-05-20")}, []int64{2, 3, 4}},
+	}
+	runContract(t, func(t *testing.T, r repositories) {
+		seedContract(t, r)
+		for _, tt := range tests {
+			page, err := r.books.GetAll(tt.filter, domain.PageQuery{Page: 1, Limit: 10})
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if got := bookIDs(page.Items); !slices.Equal(got, tt.want) || page.Total != int64(len(tt.want)) {
+				t.Errorf("%s: got books %v of %d, want %v", tt.name, got, page.Total, tt.want)
+			}
+		}
+	})
+}
+
+func TestBookRepositoryCopiesAndTags(t *testing.T) {
+	yes, no := true, false
+	runContract(t, func(t *testing.T, r repositories) {
+		seedContract(t, r)
+		classic := &domain.Tag{Name: "classic"}
+		if err := r.tags.Save(classic); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.tags.Save(&domain.Tag{Name: "classic"}); !errors.Is(err, domain.ErrDuplicateTag) {
+			t.Errorf("saving a taken tag name: got %v, want %v", err, domain.ErrDuplicateTag)
+		}
+		copies := []*domain.BookCopy{
+			{BookID: 1, Barcode: "B1"},
+			{BookID: 1, Barcode: "B2", Status: domain.CopyOnLoan},
+			{BookID: 3, Barcode: "B3", Status: domain.CopyOnLoan},
+		}
+		for _, c := range copies {
+			if err := r.copies.Save(c); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := r.copies.Save(&domain.BookCopy{BookID: 2, Barcode: "B1"}); !errors.Is(err, domain.ErrBarcodeTaken) {
+			t.Errorf("saving a taken barcode: got %v, want %v", err, domain.ErrBarcodeTaken)
+		}
+
+		dune, err := r.books.GetByID(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dune.TotalCopies != 2 || dune.AvailableCopies != 1 || !dune.Available {
+			t.Errorf("got %d copies, %d available, available %v, want 2, 1, true",
+				dune.TotalCopies, dune.AvailableCopies, dune.Available)
+		}
+		dune.Tags = []domain.Tag{{ID: classic.ID}}
+		if _, err := r.books.Save(dune); err != nil {
+			t.Fatal(err)
+		}
+		// saving without tags keeps the links
+		dune.Tags = nil
+		if _, err := r.books.Save(dune); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := r.books.GetByID(1); len(got.Tags) != 1 || got.Tags[0].Name != "classic" {
+			t.Errorf("got tags %v, want classic", got.Tags)
+		}
+
+		tests := []struct {
+			name   string
+			filter domain.BookFilter
+			want   []int64
+		}{
+			{"available", domain.BookFilter{Available: &yes}, []int64{1}},
+			{"not available", domain.BookFilter{Available: &no}, []int64{2, 3, 4, 5}},
+			{"tag id", domain.BookFilter{TagID: classic.ID}, []int64{1}},
+			{"unknown tag id", domain.BookFilter{TagID: 99}, nil},
+		}
+		for _, tt := range tests {
+			page, err := r.books.GetAll(tt.filter, domain.PageQuery{Page: 1, Limit: 10})
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if got := bookIDs(page.Items); !slices.Equal(got, tt.want) {
+				t.Errorf("%s: got books %v, want %v", tt.name, got, tt.want)
+			}
+		}
+
+		if err := r.tags.Delete(classic.ID); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := r.books.GetByID(1); len(got.Tags) != 0 {
+			t.Errorf("got tags %v after deleting the tag, want none", got.Tags)
+		}
+	})
+}
+
+func TestBookCopyRepositorySave(t *testing.T) {
+	runContract(t, func(t *testing.T, r repositories) {
+		seedContract(t, r)
+		c := &domain.BookCopy{BookID: 1, Barcode: "B1"}
+		if err := r.copies.Save(c); err != nil {
+			t.Fatal(err)
+		}
+		// a checkout lends the copy while a librarian edits it
+		if err := r.copies.Save(&domain.BookCopy{ID: c.ID, BookID: 1, Barcode: "B1", Status: domain.CopyOnLoan}); err != nil {
+			t.Fatal(err)
+		}
+		edit := &domain.BookCopy{ID: c.ID, BookID: 1, Barcode: "B1", ShelfLocation: "A-12"}
+		if err := r.copies.Save(edit); err != nil {
+			t.Fatal(err)
+		}
+		if edit.Status != domain.CopyOnLoan || edit.ShelfLocation != "A-12" {
+			t.Errorf("got status %q on shelf %q, want on_loan on A-12", edit.Status, edit.ShelfLocation)
+		}
+		edit.Status = domain.CopyMaintenance
+		if err := r.copies.Save(edit); !errors.Is(err, domain.ErrCopyOnLoan) {
+			t.Errorf("changing the status of a lent copy: got %v, want %v", err, domain.ErrCopyOnLoan)
+		}
+		if got, _ := r.copies.GetByID(c.ID); got.Status != domain.CopyOnLoan {
+			t.Errorf("got status %q, want on_loan", got.Status)
+		}
+		if err := r.copies.Save(&domain.BookCopy{ID: 99, BookID: 1, Barcode: "B9"}); !errors.Is(err, domain.ErrCopyNotFound) {
+			t.Errorf("saving an unknown copy: got %v, want %v", err, domain.ErrCopyNotFound)
+		}
+	})
+}
+
+func TestBookRepositoryGetAllPages(t *testing.T) {
+	sort := []domain.Sort{{Field: "title"}, {Field: "year", Desc: true}}
+	runContract(t, func(t *testing.T, r repositories) {
+		seedContract(t, r)
+
+		// walk forward by cursor, then back from the last page
+		q := domain.PageQuery{Page: 1, Limit: 2, Sort: sort}
+		var pages [][]int64
+		var last *domain.Page[*domain.Book]
+		for {
+			page, err := r.books.GetAll(domain.BookFilter{}, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pages, last = append(pages, bookIDs(page.Items)), page
+			if page.NextCursor == nil {
+				break
+			}
+			q.Cursor = sendCursor(t, page.NextCursor)
+		}
+		want := [][]int64{{1, 5}, {2, 4}, {3}}
+		if !slices.EqualFunc(pages, want, slices.Equal) {
+			t.Fatalf("got pages %v, want %v", pages, want)
+		}
+
+		pages = nil
+		for page := last; page.PrevCursor != nil; {
+			q.Cursor = sendCursor(t, page.PrevCursor)
+			var err error
+			if page, err = r.books.GetAll(domain.BookFilter{}, q); err != nil {
+				t.Fatal(err)
+			}
+			if page.NextCursor == nil {
+				t.Errorf("page %v reached backward has no next cursor", bookIDs(page.Items))
+			}
+			pages = append(pages, bookIDs(page.Items))
+		}
+		want = [][]int64{{2, 4}, {1, 5}}
+		if !slices.EqualFunc(pages, want, slices.Equal) {
+			t.Errorf("got pages %v going back, want %v", pages, want)
+		}
+
+		page, err := r.books.GetAll(domain.BookFilter{}, domain.PageQuery{Page: 2, Limit: 2, Sort: sort})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := bookIDs(page.Items); !slices.Equal(got, []int64{2, 4}) || page.Total != 5 || page.PrevCursor == nil {
+			t.Errorf("got page 2 %v of %d, want [2 4] of 5 with a previous page", got, page.Total)
+		}
+
+		_, err = r.books.GetAll(domain.BookFilter{}, domain.PageQuery{Page: 1, Limit: 2, Sort: []domain.Sort{{Field: "summary"}}})
+		if !errors.Is(err, domain.ErrInvalidSort) {
+			t.Errorf("sorting by summary: got %v, want %v", err, domain.ErrInvalidSort)
+		}
+		_, err = r.books.GetAll(domain.BookFilter{}, domain.PageQuery{Limit: 2, Cursor: &domain.Cursor{Values: []any{1.0}}, Sort: sort})
+		if !errors.Is(err, domain.ErrInvalidCursor) {
+			t.Errorf("a cursor of the wrong sort: got %v, want %v", err, domain.ErrInvalidCursor)
+		}
+		for _, values := range [][]any{{1965.0, 1965.0, 1.0}, {"Dune", "1965", 1.0}, {"Dune", 1965.0, nil}, {"Dune", 1965.0, true}} {
+			_, err = r.books.GetAll(domain.BookFilter{}, domain.PageQuery{Limit: 2, Cursor: &domain.Cursor{Values: values}, Sort: sort})
+			if !errors.Is(err, domain.ErrInvalidCursor) {
+				t.Errorf("a cursor of values %v: got %v, want %v", values, err, domain.ErrInvalidCursor)
+			}
+		}
+	})
+}
+
+func TestBookRepositoryStream(t *testing.T) {
+	runContract(t, func(t *testing.T, r repositories) {
+		seedContract(t, r)
+		var got []int64
+		err := r.books.Stream(domain.BookFilter{Categories: []uint{2}}, 2, func(b *domain.Book) error {
+			got = append(got, b.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []int64{1, 2, 5}; !slices.Equal(got, want) {
+			t.Errorf("streamed books %v, want %v", got, want)
+		}
+
+		stop := errors.New("stop")
+		err = r.books.Stream(domain.BookFilter{}, 2, func(b *domain.Book) error { return stop })
+		if !errors.Is(err, stop) {
+			t.Errorf("got %v from Stream, want the error of fn", err)
+		}
+	})
+}
+
+func TestCategoryRepositorySave(t *testing.T) {
+	runContract(t, func(t *testing.T, r repositories) {
+		seedContract(t, r)
+
+		c, err := r.categories.GetByName("History")
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Name = "Ancient History"
+		if err := r.categories.Save(c); err != nil {
+			t.Fatal(err)
+		}
+		if c.Version != 2 {
+			t.Errorf("got version %d after an update, want 2", c.Version)
+		}
+		c.Version = 1
+		if err := r.categories.Save(c); !errors.Is(err, domain.ErrVersionMismatch) {
+			t.Errorf("saving a stale version: got %v, want %v", err, domain.ErrVersionMismatch)
+		}
+		if err := r.categories.Save(&domain.Category{Name: "Fiction"}); !errors.Is(err, domain.ErrConflict) {
+			t.Errorf("saving a taken name: got %v, want %v", err, domain.ErrConflict)
+		}
+		if err := r.categories.Save(&domain.Category{ID: 99, Name: "Poetry"}); !errors.Is(err, domain.ErrCategoryNotFound) {
+			t.Errorf("updating an unknown category: got %v, want %v", err, domain.ErrCategoryNotFound)
+		}
+		if _, err := r.categories.GetByID(99); !errors.Is(err, domain.ErrCategoryNotFound) {
+			t.Errorf("getting an unknown category: got %v, want %v", err, domain.ErrCategoryNotFound)
+		}
+
+		if ids, _ := r.categories.GetDescendantIDs(1); !slices.Equal(ids, []uint{1, 2}) {
+			t.Errorf("got descendants %v of Fiction, want [1 2]", ids)
+		}
+		if ids, _ := r.categories.GetDescendantIDs(99); len(ids) != 0 {
+			t.Errorf("got descendants %v of an unknown category, want none", ids)
+		}
+		history := uint(3)
+		if err := r.categories.SetParent(1, &history); err != nil {
+			t.Fatal(err)
+		}
+		if ids, _ := r.categories.GetDescendantIDs(3); !slices.Equal(ids, []uint{3, 1, 2}) {
+			t.Errorf("got descendants %v of History, want [3 1 2]", ids)
+		}
+		if err := r.categories.SetParent(99, nil); !errors.Is(err, domain.ErrCategoryNotFound) {
+			t.Errorf("moving an unknown category: got %v, want %v", err, domain.ErrCategoryNotFound)
+		}
+		sf := uint(2)
+		if err := r.categories.SetParent(3, &sf); !errors.Is(err, domain.ErrCategoryCycle) {
+			t.Errorf("moving a category below itself: got %v, want %v", err, domain.ErrCategoryCycle)
+		}
+		if err := r.categories.SetParent(3, &history); !errors.Is(err, domain.ErrCategoryCycle) {
+			t.Errorf("making a category its own parent: got %v, want %v", err, domain.ErrCategoryCycle)
+		}
+		missing := uint(99)
+		if err := r.categories.SetParent(3, &missing); !errors.Is(err, domain.ErrCategoryNotFound) {
+			t.Errorf("moving a category under an unknown one: got %v, want %v", err, domain.ErrCategoryNotFound)
+		}
+		if err := r.categories.Save(&domain.Category{ID: 3, Name: "History", ParentID: &sf}); !errors.Is(err, domain.ErrCategoryCycle) {
+			t.Errorf("saving a category below itself: got %v, want %v", err, domain.ErrCategoryCycle)
+		}
+		if err := r.categories.Save(&domain.Category{Name: "Poetry", ParentID: &missing}); !errors.Is(err, domain.ErrCategoryNotFound) {
+			t.Errorf("creating a category under an unknown one: got %v, want %v", err, domain.ErrCategoryNotFound)
+		}
+
+		all, err := r.categories.GetAll()
+		if err != nil || len(all) != 3 {
+			t.Errorf("got %d categories, %v; want 3", len(all), err)
+		}
+	})
+}
+
+func TestCategoryRepositoryGetByFilterAll(t *testing.T) {
+	runContract(t, func(t *testing.T, r repositories) {
+		seedContract(t, r)
+		for _, name := range []string{"Science", "Social Science"} {
+			if err := r.categories.Save(&domain.Category{Name: name}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		q := domain.PageQuery{Page: 1, Limit: 2, Sort: []domain.Sort{{Field: "name", Desc: true}}}
+		var got []string
+		for {
+			page, err := r.categories.GetByFilterAll("i", q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Total != 4 {
+				t.Errorf("got total %d, want 4", page.Total)
+			}
+			for _, c := range page.Items {
+				got = append(got, c.Name)
+			}
+			if page.NextCursor == nil {
+				break
+			}
+			q.Cursor = sendCursor(t, page.NextCursor)
+		}
+		if want := []string{"Social Science", "Science", "History", "Fiction"}; !slices.Equal(got, want) {
+			t.Errorf("got categories %v, want %v", got, want)
+		}
+	})
+}
+
+func TestCategoryRepositoryDelete(t *testing.T) {
+	runContract(t, func(t *testing.T, r repositories) {
+		seedContract(t, r)
+
+		var inUse *domain.CategoryInUseError
+		if _, err := r.categories.Delete(2, domain.CategoryDeletion{}); !errors.As(err, &inUse) || inUse.Books != 3 {
+			t.Errorf("deleting a category with books: got %v, want it in use by 3 books", err)
+		}
+		if _, err := r.categories.Delete(2, domain.CategoryDeletion{ReassignTo: 2}); !errors.Is(err, domain.ErrInvalidReassign) {
+			t.Errorf("reassigning to the same category: got %v, want %v", err, domain.ErrInvalidReassign)
+		}
+		if _, err := r.categories.Delete(2, domain.CategoryDeletion{Version: 5, Cascade: true}); !errors.Is(err, domain.ErrVersionMismatch) {
+			t.Errorf("deleting a stale version: got %v, want %v", err, domain.ErrVersionMismatch)
+		}
+
+		moved, err := r.categories.Delete(2, domain.CategoryDeletion{ReassignTo: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(moved, []int64{1, 2, 5}) {
+			t.Errorf("got moved books %v, want [1 2 5]", moved)
+		}
+		if b, _ := r.books.GetByID(1); b.CategoryID != 3 || b.Version != 2 {
+			t.Errorf("got category %d and version %d for a moved book, want 3 and 2", b.CategoryID, b.Version)
+		}
+
+		// Fiction's child goes to the deleted category's parent
+		fiction := uint(1)
+		if err := r.categories.Save(&domain.Category{Name: "Fantasy", ParentID: &fiction}); err != nil {
+			t.Fatal(err)
+		}
+		deleted, err := r.categories.Delete(1, domain.CategoryDeletion{Cascade: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(deleted, []int64{3}) {
+			t.Errorf("got deleted books %v, want [3]", deleted)
+		}
+		if _, err := r.books.GetByID(3); !errors.Is(err, domain.ErrBookNotFound) {
+			t.Errorf("getting a cascaded book: got %v, want %v", err, domain.ErrBookNotFound)
+		}
+		fantasy, err := r.categories.GetByName("Fantasy")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fantasy.ParentID != nil || fantasy.Version != 2 {
+			t.Errorf("got parent %v and version %d for an orphaned category, want none and 2", fantasy.ParentID, fantasy.Version)
+		}
+		if _, err := r.categories.Delete(1, domain.CategoryDeletion{}); !errors.Is(err, domain.ErrCategoryNotFound) {
+			t.Errorf("deleting a deleted category: got %v, want %v", err, domain.ErrCategoryNotFound)
+		}
+	})
+}
+
+func TestCategoryRepositoryMerge(t *testing.T) {
+	runContract(t, func(t *testing.T, r repositories) {
+		seedContract(t, r)
+
+		// a failed merge undoes the merges before it
+		_, err := r.categories.Merge([]*domain.CategoryMerge{{TargetID: 3, SourceID: 2}, {TargetID: 2, SourceID: 1}})
+		if !errors.Is(err, domain.ErrCategoryNotFound) {
+			t.Errorf("merging a merged category: got %v, want %v", err, domain.ErrCategoryNotFound)
+		}
+		if b, _ := r.books.GetByID(1); b.CategoryID != 2 {
+			t.Errorf("got category %d for a book of a failed merge, want 2", b.CategoryID)
+		}
+		if _, err := r.categories.Merge([]*domain.CategoryMerge{{TargetID: 2, SourceID: 1}}); !errors.Is(err, domain.ErrCategoryCycle) {
+			t.Errorf("merging into a subcategory: got %v, want %v", err, domain.ErrCategoryCycle)
+		}
+
+		merges := []*domain.CategoryMerge{{TargetID: 3, SourceID: 2}, {TargetID: 3, SourceID: 1}}
+		moved, err := r.categories.Merge(merges)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(moved, []int64{1, 2, 5, 3}) {
+			t.Errorf("got moved books %v, want [1 2 5 3]", moved)
+		}
+		if merges[0].SourceName != "SF" || merges[0].BooksMoved != 3 || merges[1].BooksMoved != 1 {
+			t.Errorf("got merges %+v, want SF with 3 books then Fiction with 1", merges)
+		}
+		got, err := r.categories.GetMerges(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 || got[0].SourceName != "Fiction" || got[1].SourceName != "SF" {
+			t.Errorf("got merges %+v, want Fiction then SF", got)
+		}
+		page, err := r.books.GetAll(domain.BookFilter{Categories: []uint{3}}, domain.PageQuery{Page: 1, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 5 {
+			t.Errorf("got %d books in the target, want 5", page.Total)
+		}
+	})
+}
+
+func TestUserRepository(t *testing.T) {
+	runContract(t, func(t *testing.T, r repositories) {
+		u := &domain.User{ID: uuid.New(), Username: "ada", Password: "hash", Role: domain.RoleMember}
+		id, err := r.users.CreateUser(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != u.ID.String() {
+			t.Errorf("got id %s, want %s", id, u.ID)
+		}
+		_, err = r.users.CreateUser(&domain.User{ID: uuid.New(), Username: "ada", Password: "hash", Role: domain.RoleMember})
+		if !errors.Is(err, domain.ErrConflict) {
+			t.Errorf("creating a taken username: got %v, want %v", err, domain.ErrConflict)
+		}
+
+		if err := r.users.UpdateRole(u.ID, domain.RoleLibrarian); err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.users.GetByUsername("ada")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ID != u.ID || got.Role != domain.RoleLibrarian {
+			t.Errorf("got user %s with role %s, want %s with role librarian", got.ID, got.Role, u.ID)
+		}
+		if _, err := r.users.GetByID(uuid.New()); !errors.Is(err, domain.ErrUserNotFound) {
+			t.Errorf("getting an unknown user: got %v, want %v", err, domain.ErrUserNotFound)
+		}
+		if err := r.users.UpdateRole(uuid.New(), domain.RoleAdmin); !errors.Is(err, domain.ErrUserNotFound) {
+			t.Errorf("updating an unknown user: got %v, want %v", err, domain.ErrUserNotFound)
+		}
+	})
+}
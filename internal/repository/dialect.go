@@ -0,0 +1,13 @@
+package repository
+
+import "gorm.io/gorm"
+
+// containsCondition is the condition of column containing the argument,
+// bound as a LIKE pattern, regardless of case. LIKE ignores case in MySQL and
+// SQLite but not in PostgreSQL.
+func containsCondition(db *gorm.DB, column string) string {
+	if db.Dialector.Name() == "postgres" {
+		return column + " ILIKE ?"
+	}
+	return column + " LIKE ?"
+}
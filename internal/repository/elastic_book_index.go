@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+)
+
+// bookDocument is the searchable projection of a book.
+type bookDocument struct {
+	ID           int64  `json:"id"`
+	Title        string `json:"title"`
+	Author       string `json:"author"`
+	ISBN         string `json:"isbn"`
+	Year         int    `json:"year"`
+	CategoryID   uint   `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	Summary      string `json:"summary"`
+}
+
+func newBookDocument(b *domain.Book) bookDocument {
+	return bookDocument{
+		ID:           b.ID,
+		Title:        b.Title,
+		Author:       b.Author,
+		ISBN:         b.ISBN,
+		Year:         b.Year,
+		CategoryID:   b.CategoryID,
+		CategoryName: b.Category.Name,
+		Summary:      b.Summary,
+	}
+}
+
+const bookIndexMapping = `{
+  "mappings": {
+    "properties": {
+      "id":            {"type": "long"},
+      "title":         {"type": "text"},
+      "author":        {"type": "text"},
+      "isbn":          {"type": "keyword"},
+      "year":          {"type": "integer"},
+      "category_id":   {"type": "long"},
+      "category_name": {"type": "keyword"},
+      "summary":       {"type": "text"}
+    }
+  }
+}`
+
+// ElasticBookIndex talks to the Elasticsearch REST API directly.
+type ElasticBookIndex struct {
+	baseURL  string
+	index    string
+	username string
+	password string
+	client   *http.Client
+}
+
+// Index implements domain.BookSearchIndex.
+func (e *ElasticBookIndex) Index(b *domain.Book) error {
+	body, err := json.Marshal(newBookDocument(b))
+	if err != nil {
+		return err
+	}
+	return e.do(http.MethodPut, "/"+e.index+"/_doc/"+strconv.FormatInt(b.ID, 10), body, nil)
+}
+
+// Remove implements domain.BookSearchIndex.
+func (e *ElasticBookIndex) Remove(id int64) error {
+	err := e.do(http.MethodDelete, "/"+e.index+"/_doc/"+strconv.FormatInt(id, 10), nil, nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// Search implements domain.BookSearchIndex.
+func (e *ElasticBookIndex) Search(q domain.BookSearchQuery) (*domain.BookSearchResult, error) {
+	body, err := json.Marshal(buildSearchRequest(q))
+	if err != nil {
+		return nil, err
+	}
+	var res elasticSearchResponse
+	if err := e.do(http.MethodPost, "/"+e.index+"/_search", body, &res); err != nil {
+		return nil, err
+	}
+
+	result := &domain.BookSearchResult{
+		Hits:   make([]domain.BookSearchHit, 0, len(res.Hits.Hits)),
+		Total:  res.Hits.Total.Value,
+		Facets: map[string][]domain.FacetBucket{},
+	}
+	for _, h := range res.Hits.Hits {
+		result.Hits = append(result.Hits, domain.BookSearchHit{
+			BookID:     h.Source.ID,
+			Score:      h.Score,
+			Highlights: h.Highlight,
+		})
+	}
+	for _, b := range res.Aggregations.Category.Buckets {
+		bucket := domain.FacetBucket{Key: string(b.Key), Count: b.DocCount}
+		if len(b.Name.Buckets) > 0 {
+			bucket.Label = strings.Trim(string(b.Name.Buckets[0].Key), `"`)
+		}
+		result.Facets["category"] = append(result.Facets["category"], bucket)
+	}
+	for _, b := range res.Aggregations.Year.Buckets {
+		result.Facets["year"] = append(result.Facets["year"], domain.FacetBucket{Key: string(b.Key), Count: b.DocCount})
+	}
+	return result, nil
+}
+
+// EnsureIndex creates the index with its mapping when it does not exist yet.
+func (e *ElasticBookIndex) EnsureIndex() error {
+	err := e.do(http.MethodHead, "/"+e.index, nil, nil)
+	if !isNotFound(err) {
+		return err
+	}
+	return e.do(http.MethodPut, "/"+e.index, []byte(bookIndexMapping), nil)
+}
+
+// buildSearchRequest ranks title, author and ISBN matches above summary ones
+// and applies the filters as a post filter so the facets keep their counts.
+func buildSearchRequest(q domain.BookSearchQuery) map[string]interface{} {
+	query := map[string]interface{}{"match_all": map[string]interface{}{}}
+	if strings.TrimSpace(q.Query) != "" {
+		query = map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":     q.Query,
+				"fields":    []string{"title^3", "author^2", "isbn^4", "summary"},
+				"fuzziness": "AUTO",
+			},
+		}
+	}
+
+	var filters []interface{}
+	if q.Category != 0 {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"category_id": q.Category}})
+	}
+	if q.Year != 0 {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"year": q.Year}})
+	}
+
+	req := map[string]interface{}{
+		"from":  (q.Page - 1) * q.Limit,
+		"size":  q.Limit,
+		"query": query,
+		"highlight": map[string]interface{}{
+			"fields": map[string]interface{}{
+				"title":   map[string]interface{}{},
+				"author":  map[string]interface{}{},
+				"summary": map[string]interface{}{},
+			},
+		},
+		"aggs": map[string]interface{}{
+			"category": map[string]interface{}{
+				"terms": map[string]interface{}{"field": "category_id"},
+				"aggs": map[string]interface{}{
+					"name": map[string]interface{}{"terms": map[string]interface{}{"field": "category_name", "size": 1}},
+				},
+			},
+			"year": map[string]interface{}{
+				"terms": map[string]interface{}{"field": "year", "order": map[string]string{"_key": "desc"}},
+			},
+		},
+	}
+	if len(filters) > 0 {
+		req["post_filter"] = map[string]interface{}{"bool": map[string]interface{}{"filter": filters}}
+	}
+	return req
+}
+
+type elasticBucket struct {
+	Key      json.RawMessage `json:"key"`
+	DocCount int64           `json:"doc_count"`
+	Name     struct {
+		Buckets []elasticBucket `json:"buckets"`
+	} `json:"name"`
+}
+
+type elasticSearchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			Score     float64             `json:"_score"`
+			Source    bookDocument        `json:"_source"`
+			Highlight map[string][]string `json:"highlight"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations struct {
+		Category struct {
+			Buckets []elasticBucket `json:"buckets"`
+		} `json:"category"`
+		Year struct {
+			Buckets []elasticBucket `json:"buckets"`
+		} `json:"year"`
+	} `json:"aggregations"`
+}
+
+type elasticError struct {
+	status int
+	body   string
+}
+
+func (e *elasticError) Error() string {
+	return fmt.Sprintf("elasticsearch: status %d: %s", e.status, e.body)
+}
+
+func isNotFound(err error) bool {
+	ee, ok := err.(*elasticError)
+	return ok && ee.status == http.StatusNotFound
+}
+
+func (e *ElasticBookIndex) do(method, path string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, e.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.username != "" {
+		req.SetBasicAuth(e.username, e.password)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &elasticError{status: resp.StatusCode, body: string(msg)}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// NewElasticBookIndex builds an index client; a nil client uses a default
+// one with a short timeout.
+func NewElasticBookIndex(baseURL, index, username, password string, client *http.Client) *ElasticBookIndex {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &ElasticBookIndex{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		index:    index,
+		username: username,
+		password: password,
+		client:   client,
+	}
+}
@@ -0,0 +1,141 @@
+package repository_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/repository"
+)
+
+const elasticSearchResponse = `{
+  "hits": {
+    "total": {"value": 12},
+    "hits": [
+      {"_score": 7.5, "_source": {"id": 3, "title": "Dune"}, "highlight": {"title": ["<em>Dune</em>"]}},
+      {"_score": 1.25, "_source": {"id": 9, "title": "Dune Messiah"}}
+    ]
+  },
+  "aggregations": {
+    "category": {"buckets": [
+      {"key": 2, "doc_count": 10, "name": {"buckets": [{"key": "Science Fiction", "doc_count": 10}]}},
+      {"key": 5, "doc_count": 2, "name": {"buckets": []}}
+    ]},
+    "year": {"buckets": [{"key": 1965, "doc_count": 1}, {"key": 1969, "doc_count": 11}]}
+  }
+}`
+
+func TestElasticBookIndexSearch(t *testing.T) {
+	var method, path, user string
+	var request map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		user, _, _ = r.BasicAuth()
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Errorf("request body %s: %v", body, err)
+		}
+		io.WriteString(w, elasticSearchResponse)
+	}))
+	defer srv.Close()
+
+	index := repository.NewElasticBookIndex(srv.URL+"/", "books", "elastic", "secret", nil)
+	result, err := index.Search(domain.BookSearchQuery{Query: "dune", Category: 2, Page: 3, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if method != http.MethodPost || path != "/books/_search" || user != "elastic" {
+		t.Errorf("got %s %s as %q, want POST /books/_search as elastic", method, path, user)
+	}
+	if request["from"] != float64(20) || request["size"] != float64(10) {
+		t.Errorf("got from %v size %v, want 20 and 10", request["from"], request["size"])
+	}
+	match, _ := request["query"].(map[string]interface{})["multi_match"].(map[string]interface{})
+	if match["query"] != "dune" || match["fuzziness"] != "AUTO" {
+		t.Errorf("got query %v, want a fuzzy multi_match on dune", request["query"])
+	}
+	// the filter must not narrow the facets
+	filter := `{"bool":{"filter":[{"term":{"category_id":2}}]}}`
+	if got, _ := json.Marshal(request["post_filter"]); string(got) != filter {
+		t.Errorf("got post_filter %s, want %s", got, filter)
+	}
+
+	if result.Total != 12 || len(result.Hits) != 2 {
+		t.Fatalf("got %d of %d hits, want 2 of 12", len(result.Hits), result.Total)
+	}
+	first := result.Hits[0]
+	if first.BookID != 3 || first.Score != 7.5 || !reflect.DeepEqual(first.Highlights, map[string][]string{"title": {"<em>Dune</em>"}}) {
+		t.Errorf("got first hit %+v", first)
+	}
+	wantCategories := []domain.FacetBucket{{Key: "2", Label: "Science Fiction", Count: 10}, {Key: "5", Count: 2}}
+	if !reflect.DeepEqual(result.Facets["category"], wantCategories) {
+		t.Errorf("got category facets %+v, want %+v", result.Facets["category"], wantCategories)
+	}
+	wantYears := []domain.FacetBucket{{Key: "1965", Count: 1}, {Key: "1969", Count: 11}}
+	if !reflect.DeepEqual(result.Facets["year"], wantYears) {
+		t.Errorf("got year facets %+v, want %+v", result.Facets["year"], wantYears)
+	}
+}
+
+func TestElasticBookIndexMatchAll(t *testing.T) {
+	var request map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&request)
+		io.WriteString(w, `{}`)
+	}))
+	defer srv.Close()
+
+	index := repository.NewElasticBookIndex(srv.URL, "books", "", "", nil)
+	if _, err := index.Search(domain.BookSearchQuery{Query: "  ", Page: 1, Limit: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := request["query"].(map[string]interface{})["match_all"]; !ok {
+		t.Errorf("got query %v for a blank search, want match_all", request["query"])
+	}
+	if _, ok := request["post_filter"]; ok {
+		t.Errorf("got post_filter %v without filters", request["post_filter"])
+	}
+}
+
+func TestElasticBookIndexWrites(t *testing.T) {
+	status := http.StatusOK
+	var method, path string
+	var doc map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		doc = nil
+		json.NewDecoder(r.Body).Decode(&doc)
+		w.WriteHeader(status)
+		io.WriteString(w, `{"result":"ok"}`)
+	}))
+	defer srv.Close()
+	index := repository.NewElasticBookIndex(srv.URL, "books", "", "", nil)
+
+	book := &domain.Book{ID: 7, Title: "Emma", Author: "Jane Austen", CategoryID: 2, Category: domain.Category{Name: "Classics"}}
+	if err := index.Index(book); err != nil {
+		t.Fatal(err)
+	}
+	if method != http.MethodPut || path != "/books/_doc/7" || doc["title"] != "Emma" || doc["category_name"] != "Classics" {
+		t.Errorf("got %s %s with %v, want PUT /books/_doc/7 with the book", method, path, doc)
+	}
+
+	if err := index.Remove(7); err != nil || method != http.MethodDelete || path != "/books/_doc/7" {
+		t.Errorf("got %s %s: %v, want DELETE /books/_doc/7", method, path, err)
+	}
+	status = http.StatusNotFound
+	if err := index.Remove(8); err != nil {
+		t.Errorf("removing a missing document: %v", err)
+	}
+	status = http.StatusInternalServerError
+	if err := index.Remove(8); err == nil {
+		t.Error("removing a document when the server fails: got no error")
+	}
+	if err := index.Index(book); err == nil {
+		t.Error("indexing a document when the server fails: got no error")
+	}
+}
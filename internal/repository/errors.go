@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/glebarez/go-sqlite"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// MySQL errors that gorm does not translate but that are caused by the data
+// sent rather than by the database.
+const (
+	mysqlColumnNotNull  = 1048
+	mysqlOutOfRange     = 1264
+	mysqlDataTooLong    = 1406
+	mysqlTruncatedValue = 1366
+)
+
+// The same errors in PostgreSQL and SQLite.
+const (
+	pgNotNullViolation      = "23502"
+	pgStringTooLong         = "22001"
+	pgNumericOutOfRange     = "22003"
+	pgInvalidText           = "22P02"
+	sqliteConstraintNotNull = 1299
+)
+
+// dbError maps a gorm or database error to a domain error kind. A missing row
+// becomes notFound, which should be the specific not-found error of the
+// entity that was looked up; errors with no domain meaning are returned
+// unchanged.
+func dbError(err error, notFound error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return notFound
+	case errors.Is(err, gorm.ErrDuplicatedKey), errors.Is(err, gorm.ErrForeignKeyViolated):
+		return fmt.Errorf("%w: %v", domain.ErrConflict, err)
+	case errors.Is(err, gorm.ErrCheckConstraintViolated), errors.Is(err, gorm.ErrInvalidData),
+		errors.Is(err, gorm.ErrInvalidValue):
+		return fmt.Errorf("%w: %v", domain.ErrValidation, err)
+	}
+	var mysqlErr *mysql.MySQLError
+	var pgErr *pgconn.PgError
+	var sqliteErr *sqlite.Error
+	switch {
+	case errors.As(err, &mysqlErr):
+		switch mysqlErr.Number {
+		case mysqlColumnNotNull, mysqlOutOfRange, mysqlDataTooLong, mysqlTruncatedValue:
+			return fmt.Errorf("%w: %v", domain.ErrValidation, err)
+		}
+	case errors.As(err, &pgErr):
+		switch pgErr.Code {
+		case pgNotNullViolation, pgStringTooLong, pgNumericOutOfRange, pgInvalidText:
+			return fmt.Errorf("%w: %v", domain.ErrValidation, err)
+		}
+	case errors.As(err, &sqliteErr):
+		if sqliteErr.Code() == sqliteConstraintNotNull {
+			return fmt.Errorf("%w: %v", domain.ErrValidation, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"gorm.io/gorm"
+)
+
+type GormAuthorRepository struct {
+	db *gorm.DB
+}
+
+// Save implements domain.AuthorRepository.
+func (g *GormAuthorRepository) Save(author *domain.Author) error {
+	var err error
+	if author.ID != 0 {
+		err = g.db.Save(author).Error
+	} else {
+		err = g.db.Create(author).Error
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return domain.ErrDuplicateAuthor
+	}
+	return dbError(err, domain.ErrAuthorNotFound)
+}
+
+// GetByFilterAll implements domain.AuthorRepository.
+func (g *GormAuthorRepository) GetByFilterAll(page, limit int, filter string) ([]*domain.Author, int64, error) {
+	var authors []*domain.Author
+	var total int64
+	query := g.db.Model(&domain.Author{})
+	if filter != "" {
+		query = query.Where(containsCondition(query, "name"), "%"+filter+"%")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * limit
+	if err := query.Order("name").Limit(limit).Offset(offset).Find(&authors).Error; err != nil {
+		return nil, 0, err
+	}
+	return authors, total, nil
+}
+
+// GetByID implements domain.AuthorRepository.
+func (g *GormAuthorRepository) GetByID(id uint) (*domain.Author, error) {
+	var author domain.Author
+	if err := g.db.First(&author, id).Error; err != nil {
+		return nil, dbError(err, domain.ErrAuthorNotFound)
+	}
+	return &author, nil
+}
+
+// GetByName implements domain.AuthorRepository.
+func (g *GormAuthorRepository) GetByName(name string) (*domain.Author, error) {
+	var author domain.Author
+	if err := g.db.Where("name = ?", name).First(&author).Error; err != nil {
+		return nil, dbError(err, domain.ErrAuthorNotFound)
+	}
+	return &author, nil
+}
+
+// Delete implements domain.AuthorRepository. An author credited on a live
+// book is kept, with ErrAuthorInUse; deleted books are unlinked.
+func (g *GormAuthorRepository) Delete(id uint) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		var credited int64
+		err := tx.Table("book_authors").
+			Joins("JOIN books ON books.id = book_authors.book_id AND books.deleted_at IS NULL").
+			Where("book_authors.author_id = ?", id).Count(&credited).Error
+		if err != nil {
+			return err
+		}
+		if credited > 0 {
+			return domain.ErrAuthorInUse
+		}
+		if err := tx.Exec("DELETE FROM book_authors WHERE author_id = ?", id).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(&domain.Author{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return domain.ErrAuthorNotFound
+		}
+		return nil
+	})
+}
+
+// BackfillBookAuthors links every book that has a byline but no authors to
+// the authors named in its byline, creating them as needed, so catalogs
+// created before authors existed can be filtered by author. Run it once,
+// right after the book_authors table is created.
+func BackfillBookAuthors(db *gorm.DB) error {
+	var books []struct {
+		ID     int64
+		Author string
+	}
+	err := db.Model(&domain.Book{}).Select("id, author").
+		Where("author <> ''").
+		Where("NOT EXISTS (?)", db.Table("book_authors").Select("1").Where("book_authors.book_id = books.id")).
+		Find(&books).Error
+	if err != nil || len(books) == 0 {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		ids := map[string]uint{}
+		for _, b := range books {
+			for _, name := range domain.SplitAuthors(b.Author) {
+				key := strings.ToLower(name)
+				if _, ok := ids[key]; !ok {
+					var author domain.Author
+					if err := tx.Where(domain.Author{Name: name}).FirstOrCreate(&author).Error; err != nil {
+						return err
+					}
+					ids[key] = author.ID
+				}
+				err := tx.Exec("INSERT INTO book_authors (book_id, author_id) VALUES (?, ?)", b.ID, ids[key]).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func NewGormAuthorRepository(db *gorm.DB) domain.AuthorRepository {
+	return &GormAuthorRepository{db: db}
+}
@@ -0,0 +1,36 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/repository"
+)
+
+func TestGormAuthorRepositoryDeleteCredited(t *testing.T) {
+	db := newTestDB(t)
+	books := repository.NewGormBookRepository(db)
+	authors := repository.NewGormAuthorRepository(db)
+	if err := db.Create(&domain.Category{ID: 1, Name: "Fiction"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	b := &domain.Book{Title: "Dune", Authors: []domain.Author{{Name: "Frank Herbert"}}, ISBN: "9780306406157", CategoryID: 1}
+	if _, err := books.Save(b); err != nil {
+		t.Fatal(err)
+	}
+	id := b.Authors[0].ID
+
+	if err := authors.Delete(id); !errors.Is(err, domain.ErrAuthorInUse) {
+		t.Errorf("deleting a credited author: got %v, want %v", err, domain.ErrAuthorInUse)
+	}
+	if err := books.Delete(b.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := authors.Delete(id); err != nil {
+		t.Errorf("deleting an author credited only on deleted books: %v", err)
+	}
+	if err := authors.Delete(id); !errors.Is(err, domain.ErrAuthorNotFound) {
+		t.Errorf("deleting a deleted author: got %v, want %v", err, domain.ErrAuthorNotFound)
+	}
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormBookCopyRepository struct {
+	db *gorm.DB
+}
+
+// Save implements domain.BookCopyRepository.
+func (g *GormBookCopyRepository) Save(c *domain.BookCopy) error {
+	var err error
+	if c.ID != 0 {
+		err = g.db.Transaction(func(tx *gorm.DB) error { return updateCopy(tx, c) })
+	} else {
+		err = g.db.Create(c).Error
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return domain.ErrBarcodeTaken
+	}
+	return dbError(err, domain.ErrCopyNotFound)
+}
+
+// updateCopy writes the edited columns of a copy while its row is locked, so
+// that a checkout or a hold promotion waits for it or is seen by it.
+func updateCopy(tx *gorm.DB, c *domain.BookCopy) error {
+	var stored domain.BookCopy
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stored, c.ID).Error; err != nil {
+		return err
+	}
+	columns := []string{"book_id", "barcode", "shelf_location", "condition", "updated_at"}
+	if c.Status != "" && c.Status != stored.Status {
+		if err := stored.Released(); err != nil {
+			return err
+		}
+		columns = append(columns, "status")
+	}
+	if err := tx.Model(c).Select(columns).Updates(c).Error; err != nil {
+		return err
+	}
+	return tx.First(c, c.ID).Error
+}
+
+// GetByID implements domain.BookCopyRepository.
+func (g *GormBookCopyRepository) GetByID(id int64) (*domain.BookCopy, error) {
+	var bookCopy domain.BookCopy
+	if err := g.db.First(&bookCopy, id).Error; err != nil {
+		return nil, dbError(err, domain.ErrCopyNotFound)
+	}
+	return &bookCopy, nil
+}
+
+// GetByBarcode implements domain.BookCopyRepository.
+func (g *GormBookCopyRepository) GetByBarcode(barcode string) (*domain.BookCopy, error) {
+	var bookCopy domain.BookCopy
+	if err := g.db.Where("barcode = ?", barcode).First(&bookCopy).Error; err != nil {
+		return nil, dbError(err, domain.ErrCopyNotFound)
+	}
+	return &bookCopy, nil
+}
+
+// GetByBook implements domain.BookCopyRepository.
+func (g *GormBookCopyRepository) GetByBook(bookID int64) ([]*domain.BookCopy, error) {
+	var copies []*domain.BookCopy
+	if err := g.db.Where("book_id = ?", bookID).Order("id").Find(&copies).Error; err != nil {
+		return nil, err
+	}
+	return copies, nil
+}
+
+// Delete implements domain.BookCopyRepository.
+func (g *GormBookCopyRepository) Delete(id int64) error {
+	res := g.db.Delete(&domain.BookCopy{}, id)
+	if res.Error != nil {
+		return dbError(res.Error, domain.ErrCopyNotFound)
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrCopyNotFound
+	}
+	return nil
+}
+
+// BackfillBookCopies gives every book without copies a single available copy,
+// so catalogs created before copies existed stay borrowable after migrating.
+// Run it once, right after the book_copies table is created.
+func BackfillBookCopies(db *gorm.DB) error {
+	var ids []int64
+	err := db.Model(&domain.Book{}).
+		Where("NOT EXISTS (?)", db.Unscoped().Model(&domain.BookCopy{}).Select("1").Where("book_copies.book_id = books.id")).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	copies := make([]domain.BookCopy, 0, len(ids))
+	for _, id := range ids {
+		copies = append(copies, domain.BookCopy{
+			BookID:  id,
+			Barcode: fmt.Sprintf("LEGACY-%d", id),
+			Status:  domain.CopyAvailable,
+		})
+	}
+	return db.CreateInBatches(copies, 100).Error
+}
+
+func NewGormBookCopyRepository(db *gorm.DB) domain.BookCopyRepository {
+	return &GormBookCopyRepository{db: db}
+}
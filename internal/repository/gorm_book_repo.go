@@ -2,71 +2,344 @@ package repository
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/abushaista/lms-backend/internal/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GormBookRepository struct {
 	db *gorm.DB
 }
 
-// Create implements domain.BookRepository.
+// Save implements domain.BookRepository.
 func (g *GormBookRepository) Save(b *domain.Book) (int64, error) {
-	if b.ID == 0 {
-		res := g.db.Create(&b)
-		return b.ID, res.Error
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		if err := resolveBookLinks(tx, b); err != nil {
+			return err
+		}
+		// links are replaced below rather than upserted with the book
+		var err error
+		if b.ID == 0 {
+			b.Version = 1
+			err = tx.Omit(clause.Associations).Create(b).Error
+		} else if b.Version, err = lockBookVersion(tx, b.ID, b.Version); err == nil {
+			b.Version++
+			err = tx.Omit(clause.Associations).Save(b).Error
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(b).Association("Authors").Replace(b.Authors); err != nil {
+			return err
+		}
+		if b.Tags != nil {
+			return tx.Model(b).Association("Tags").Replace(b.Tags)
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return b.ID, domain.ErrDuplicateISBN
+	}
+	return b.ID, dbError(err, domain.ErrBookNotFound)
+}
+
+// resolveBookLinks loads the authors and tags a book refers to by id and
+// finds or creates the authors given by name only.
+func resolveBookLinks(tx *gorm.DB, b *domain.Book) error {
+	for i, a := range b.Authors {
+		var err error
+		if a.ID != 0 {
+			err = tx.First(&b.Authors[i], a.ID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrAuthorNotFound
+			}
+		} else {
+			err = tx.Where(domain.Author{Name: a.Name}).FirstOrCreate(&b.Authors[i]).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if b.Author == "" {
+		b.Author = domain.JoinAuthors(b.Authors)
+	}
+
+	for i, t := range b.Tags {
+		err := tx.First(&b.Tags[i], t.ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrTagNotFound
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockBookVersion locks a book for a write, checks that it still has
+// version unless version is 0, and returns its current version.
+func lockBookVersion(tx *gorm.DB, id, version int64) (int64, error) {
+	var current []int64
+	err := tx.Model(&domain.Book{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).Pluck("version", &current).Error
+	switch {
+	case err != nil:
+		return 0, err
+	case len(current) == 0:
+		return 0, domain.ErrBookNotFound
+	case version != 0 && version != current[0]:
+		return 0, domain.ErrVersionMismatch
 	}
-	return b.ID, g.db.Save(b).Error
+	return current[0], nil
 }
 
-// Delete implements domain.BookRepository.
-func (g *GormBookRepository) Delete(id int64) error {
-	return g.db.Delete(&domain.Book{}, id).Error
+// Delete implements domain.BookRepository. The deleted book keeps its ISBN;
+// the unique index only covers live books, so the ISBN can be catalogued
+// again.
+func (g *GormBookRepository) Delete(id, version int64) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockBookVersion(tx, id, version); err != nil {
+			return err
+		}
+		// loans and holds of a deleted book could no longer be closed
+		inUse, err := booksInUse(tx, []int64{id})
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return domain.ErrBookInUse
+		}
+		res := tx.Delete(&domain.Book{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return domain.ErrBookNotFound
+		}
+		return nil
+	})
+}
+
+// bookKeyset maps domain.BookSortFields to columns.
+var bookKeyset = keyset[*domain.Book]{
+	columns: map[string]sortColumn[*domain.Book]{
+		"id":     {"books.id", func(b *domain.Book) any { return b.ID }},
+		"title":  {"books.title", func(b *domain.Book) any { return b.Title }},
+		"author": {"books.author", func(b *domain.Book) any { return b.Author }},
+		"year":   {"books.year", func(b *domain.Book) any { return b.Year }},
+		"isbn":   {"books.isbn", func(b *domain.Book) any { return b.ISBN }},
+	},
+	id: sortColumn[*domain.Book]{"books.id", func(b *domain.Book) any { return b.ID }},
 }
 
 // GetAll implements domain.BookRepository.
-func (g *GormBookRepository) GetAll(page, limit int, filter domain.BookFilter) ([]*domain.Book, int64, error) {
-	var books []*domain.Book
-	var total int64
-	query := g.db.Model(&domain.Book{}).Preload("Category")
+func (g *GormBookRepository) GetAll(filter domain.BookFilter, q domain.PageQuery) (*domain.Page[*domain.Book], error) {
+	query := g.db.Model(&domain.Book{}).Preload("Category").Preload("Authors").Preload("Tags").Scopes(withBookFilter(filter))
+	return bookKeyset.page(query, q, withCopyCounts)
+}
 
-	if filter.Title != "" {
-		query = query.Where("title LIKE ?", "%"+filter.Title+"%")
+// Stream implements domain.BookRepository. It walks the books in id order,
+// one batch at a time, so memory use does not grow with the catalogue.
+func (g *GormBookRepository) Stream(filter domain.BookFilter, batch int, fn func(b *domain.Book) error) error {
+	var lastID int64
+	for {
+		var books []*domain.Book
+		err := g.db.Model(&domain.Book{}).Preload("Category").Preload("Authors").Preload("Tags").
+			Scopes(withBookFilter(filter), withCopyCounts).
+			Where("books.id > ?", lastID).Order("books.id").Limit(batch).
+			Find(&books).Error
+		if err != nil {
+			return err
+		}
+		for _, b := range books {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+		if len(books) < batch {
+			return nil
+		}
+		lastID = books[len(books)-1].ID
 	}
-	if filter.Author != "" {
-		query = query.Where("author LIKE ?", "%"+filter.Author+"%")
+}
+
+// GetByID implements domain.BookRepository.
+func (g *GormBookRepository) GetByID(id int64) (*domain.Book, error) {
+	var book domain.Book
+	if err := g.db.Preload("Category").Preload("Authors").Preload("Tags").Scopes(withCopyCounts).First(&book, id).Error; err != nil {
+		return nil, dbError(err, domain.ErrBookNotFound)
 	}
-	if filter.Year != 0 {
-		query = query.Where("year = ?", filter.Year)
+	return &book, nil
+}
+
+// GetByISBN implements domain.BookRepository.
+func (g *GormBookRepository) GetByISBN(isbn string) (*domain.Book, error) {
+	var book domain.Book
+	if err := g.db.Preload("Category").Preload("Authors").Preload("Tags").Scopes(withCopyCounts).Where("isbn = ?", isbn).First(&book).Error; err != nil {
+		return nil, dbError(err, domain.ErrBookNotFound)
 	}
+	return &book, nil
+}
 
-	if filter.Category != 0 {
-		query.Where("categoryId = ?", filter.Category)
+// SetCover implements domain.BookRepository.
+func (g *GormBookRepository) SetCover(id int64, key, url string) error {
+	res := g.db.Model(&domain.Book{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"cover_image_key": key,
+		"cover_image_url": url,
+		"version":         gorm.Expr("version + 1"),
+	})
+	if res.Error != nil {
+		return dbError(res.Error, domain.ErrBookNotFound)
 	}
+	if res.RowsAffected == 0 {
+		return domain.ErrBookNotFound
+	}
+	return nil
+}
 
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+// withBookFilter applies the list filters of the book endpoints.
+func withBookFilter(filter domain.BookFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.Title != "" {
+			db = db.Where(containsCondition(db, "books.title"), "%"+filter.Title+"%")
+		}
+		if filter.Author != "" {
+			db = db.Where(containsCondition(db, "books.author"), "%"+filter.Author+"%")
+		}
+		if filter.Summary != "" {
+			db = db.Where(containsCondition(db, "books.summary"), "%"+filter.Summary+"%")
+		}
+		if filter.ISBN != "" {
+			db = db.Where("books.isbn = ?", filter.ISBN)
+		}
+		if filter.Year != 0 {
+			db = db.Where("books.year = ?", filter.Year)
+		}
+		if filter.YearFrom != 0 {
+			db = db.Where("books.year >= ?", filter.YearFrom)
+		}
+		if filter.YearTo != 0 {
+			db = db.Where("books.year <= ?", filter.YearTo)
+		}
+		if !filter.CreatedFrom.IsZero() {
+			db = db.Where("books.created_at >= ?", filter.CreatedFrom)
+		}
+		if !filter.CreatedTo.IsZero() {
+			db = db.Where("books.created_at <= ?", filter.CreatedTo)
+		}
+		if !filter.UpdatedFrom.IsZero() {
+			db = db.Where("books.updated_at >= ?", filter.UpdatedFrom)
+		}
+		if !filter.UpdatedTo.IsZero() {
+			db = db.Where("books.updated_at <= ?", filter.UpdatedTo)
+		}
+		if filter.Available != nil {
+			onShelf := db.Session(&gorm.Session{NewDB: true}).Model(&domain.BookCopy{}).Select("1").
+				Where("book_copies.book_id = books.id AND book_copies.status = ?", domain.CopyAvailable)
+			if *filter.Available {
+				db = db.Where("EXISTS (?)", onShelf)
+			} else {
+				db = db.Where("NOT EXISTS (?)", onShelf)
+			}
+		}
+		if filter.AuthorID != 0 {
+			db = db.Where("EXISTS (SELECT 1 FROM book_authors WHERE book_authors.book_id = books.id AND book_authors.author_id = ?)", filter.AuthorID)
+		}
+		if filter.TagID != 0 {
+			db = db.Where("EXISTS (SELECT 1 FROM book_tags WHERE book_tags.book_id = books.id AND book_tags.tag_id = ?)", filter.TagID)
+		}
+		if len(filter.Categories) > 0 && filter.IncludeSubcategories {
+			db = db.Where("books.category_id IN (?)", gorm.Expr(categorySubtreeSQL, filter.Categories))
+		} else if len(filter.Categories) > 0 {
+			db = db.Where("books.category_id IN ?", filter.Categories)
+		}
+		return db
 	}
+}
+
+// withCopyCounts selects the copy counters backing the computed Book.Available.
+func withCopyCounts(db *gorm.DB) *gorm.DB {
+	return db.Select("books.*, (?) AS total_copies, (?) AS available_copies",
+		db.Session(&gorm.Session{NewDB: true}).Model(&domain.BookCopy{}).
+			Select("COUNT(*)").Where("book_copies.book_id = books.id"),
+		db.Session(&gorm.Session{NewDB: true}).Model(&domain.BookCopy{}).
+			Select("COUNT(*)").Where("book_copies.book_id = books.id AND book_copies.status = ?", domain.CopyAvailable),
+	)
+}
+
+// bookISBNIndex is the unique index on the ISBNs of live books.
+const bookISBNIndex = "idx_books_live_isbn"
 
-	offset := (page - 1) * limit
-	if err := query.Limit(limit).Offset(offset).Find(&books).Error; err != nil {
-		return nil, 0, err
+// NormalizeBookISBNs prepares existing rows for the unique index on the ISBNs
+// of live books: their ISBN is rewritten as ISBN-13. ISBNs that fail the
+// checksum are left as they are. It fails, naming the books, when live books
+// share an ISBN. Run it before MigrateBookISBNIndex.
+func NormalizeBookISBNs(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&domain.Book{}) || db.Migrator().HasIndex(&domain.Book{}, bookISBNIndex) {
+		return nil
 	}
+	var rows []struct {
+		ID   int64
+		ISBN *string
+	}
+	if err := db.Model(&domain.Book{}).Select("id, isbn").Find(&rows).Error; err != nil {
+		return err
+	}
+
+	owners := map[string][]int64{}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, r := range rows {
+			if r.ISBN == nil {
+				continue
+			}
+			isbn := *r.ISBN
+			if normalized, err := domain.NormalizeISBN(isbn); err == nil && normalized != isbn {
+				if err := tx.Model(&domain.Book{}).Where("id = ?", r.ID).Update("isbn", normalized).Error; err != nil {
+					return err
+				}
+				isbn = normalized
+			}
+			owners[isbn] = append(owners[isbn], r.ID)
+		}
 
-	return books, total, nil
+		var conflicts []string
+		for isbn, ids := range owners {
+			if len(ids) > 1 {
+				conflicts = append(conflicts, fmt.Sprintf("%s (books %v)", isbn, ids))
+			}
+		}
+		if len(conflicts) > 0 {
+			sort.Strings(conflicts)
+			return fmt.Errorf("duplicate ISBNs must be merged before upgrading: %s", strings.Join(conflicts, ", "))
+		}
+		return nil
+	})
 }
 
-// GetByID implements domain.BookRepository.
-func (g *GormBookRepository) GetByID(id int64) (*domain.Book, error) {
-	var book domain.Book
-	if err := g.db.First(&book, id).Preload("Category").Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
+// MigrateBookISBNIndex makes ISBNs unique among live books, so a deleted
+// book keeps its ISBN and the ISBN can still be catalogued again. PostgreSQL
+// and SQLite get a partial index; MySQL, which has none, indexes an
+// expression that is NULL for deleted books. It replaces the unique index on
+// every row that earlier versions created. Run it after AutoMigrate.
+func MigrateBookISBNIndex(db *gorm.DB) error {
+	m := db.Migrator()
+	if m.HasIndex(&domain.Book{}, "idx_books_isbn") {
+		if err := m.DropIndex(&domain.Book{}, "idx_books_isbn"); err != nil {
+			return err
 		}
-		return nil, err
 	}
-	return &book, nil
+	if m.HasIndex(&domain.Book{}, bookISBNIndex) {
+		return nil
+	}
+	sql := "CREATE UNIQUE INDEX " + bookISBNIndex + " ON books (isbn) WHERE deleted_at IS NULL"
+	if db.Dialector.Name() == "mysql" {
+		sql = "CREATE UNIQUE INDEX " + bookISBNIndex + " ON books ((CASE WHEN deleted_at IS NULL THEN isbn END))"
+	}
+	return db.Exec(sql).Error
 }
 
 func NewGormBookRepository(db *gorm.DB) domain.BookRepository {
@@ -0,0 +1,223 @@
+package repository_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/repository"
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens a private in-memory SQLite database with the schema of the
+// catalogue, of users and of their loans and holds.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	err = db.AutoMigrate(&domain.Author{}, &domain.Tag{}, &domain.Category{}, &domain.CategoryMerge{},
+		&domain.Book{}, &domain.BookCopy{}, &domain.User{}, &domain.Loan{}, &domain.Reservation{}, &domain.Fine{})
+	if err == nil {
+		err = repository.MigrateBookISBNIndex(db)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// seedCatalogue stores Fiction (1) with SF (2) below it and History (3), and
+// four books with their copies.
+func seedCatalogue(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	fiction := uint(1)
+	categories := []*domain.Category{
+		{ID: 1, Name: "Fiction"},
+		{ID: 2, Name: "SF", ParentID: &fiction},
+		{ID: 3, Name: "History"},
+	}
+	books := []*domain.Book{
+		{ID: 1, Title: "Dune", Author: "Frank Herbert", ISBN: "9780306406157", Year: 1965, CategoryID: 2,
+			Summary: "a desert planet", CreatedAt: date("2024-01-10"), UpdatedAt: date("2024-03-01")},
+		{ID: 2, Title: "Foundation", Author: "Isaac Asimov", ISBN: "9780198526636", Year: 1951, CategoryID: 2,
+			Summary: "a galactic empire falls", CreatedAt: date("2024-02-05"), UpdatedAt: date("2024-02-05")},
+		{ID: 3, Title: "The Hobbit", Author: "J.R.R. Tolkien", ISBN: "9780131103627", Year: 1937, CategoryID: 1,
+			Summary: "there and back again", CreatedAt: date("2024-03-15"), UpdatedAt: date("2024-06-01")},
+		{ID: 4, Title: "SPQR", Author: "Mary Beard", ISBN: "9780262033848", Year: 2015, CategoryID: 3,
+			Summary: "a history of ancient Rome", CreatedAt: date("2024-05-20"), UpdatedAt: date("2024-05-20")},
+	}
+	copies := []*domain.BookCopy{
+		{BookID: 1, Barcode: "B1-1", Status: domain.CopyAvailable},
+		{BookID: 2, Barcode: "B2-1", Status: domain.CopyOnLoan},
+		{BookID: 4, Barcode: "B4-1", Status: domain.CopyOnLoan},
+		{BookID: 4, Barcode: "B4-2", Status: domain.CopyAvailable},
+	}
+	for _, rows := range []any{categories, books, copies} {
+		if err := db.Create(rows).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGormBookRepositoryGetAllFilters(t *testing.T) {
+	db := newTestDB(t)
+	seedCatalogue(t, db)
+	repo := repository.NewGormBookRepository(db)
+	yes, no := true, false
+
+	tests := []struct {
+		name   string
+		filter domain.BookFilter
+		want   []int64
+	}{
+		{"no filter", domain.BookFilter{}, []int64{1, 2, 3, 4}},
+		{"title substring", domain.BookFilter{Title: "o"}, []int64{2, 3}},
+		{"author substring", domain.BookFilter{Author: "Asimov"}, []int64{2}},
+		{"summary substring", domain.BookFilter{Summary: "history"}, []int64{4}},
+		{"isbn exact", domain.BookFilter{ISBN: "9780131103627"}, []int64{3}},
+		{"isbn is not a substring match", domain.BookFilter{ISBN: "978013110362"}, nil},
+		{"one category", domain.BookFilter{Categories: []uint{2}}, []int64{1, 2}},
+		{"several categories", domain.BookFilter{Categories: []uint{1, 3}}, []int64{3, 4}},
+		{"category with subcategories", domain.BookFilter{Categories: []uint{1}, IncludeSubcategories: true}, []int64{1, 2, 3}},
+		{"categories with subcategories", domain.BookFilter{Categories: []uint{2, 3}, IncludeSubcategories: true}, []int64{1, 2, 4}},
+		{"exact year", domain.BookFilter{Year: 1951}, []int64{2}},
+		{"year range", domain.BookFilter{YearFrom: 1940, YearTo: 1965}, []int64{1, 2}},
+		{"open year range", domain.BookFilter{YearFrom: 1960}, []int64{1, 4}},
+		{"available", domain.BookFilter{Available: &yes}, []int64{1, 4}},
+		{"not available", domain.BookFilter{Available: &no}, []int64{2, 3}},
+		{"created range", domain.BookFilter{CreatedFrom: date("2024-02-05"), CreatedTo: date("2024-03-15")}, []int64{2, 3}},
+		{"updated since", domain.BookFilter{UpdatedFrom: date("2024-05-01")}, []int64{3, 4}},
+		{"updated until", domain.BookFilter{UpdatedTo: date("2024-02-28")}, []int64{2}},
+		{"combined", domain.BookFilter{Categories: []uint{2}, Available: &no, YearTo: 1960}, []int64{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := repo.GetAll(tt.filter, domain.PageQuery{Page: 1, Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int64
+			for _, b := range page.Items {
+				got = append(got, b.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got books %v, want %v", got, tt.want)
+			}
+			if page.Total != int64(len(tt.want)) {
+				t.Errorf("got total %d, want %d", page.Total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestGormBookRepositoryGetAllCopyCounts(t *testing.T) {
+	db := newTestDB(t)
+	seedCatalogue(t, db)
+	repo := repository.NewGormBookRepository(db)
+
+	page, err := repo.GetAll(domain.BookFilter{Categories: []uint{3}}, domain.PageQuery{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 1 {
+		t.Fatalf("got %d books, want 1", len(page.Items))
+	}
+	b := page.Items[0]
+	if b.TotalCopies != 2 || b.AvailableCopies != 1 || !b.Available {
+		t.Errorf("got %d copies, %d available, available=%v; want 2, 1, true", b.TotalCopies, b.AvailableCopies, b.Available)
+	}
+}
+
+// A deleted book keeps its ISBN without blocking it for a new book.
+func TestGormBookRepositoryDeleteKeepsISBN(t *testing.T) {
+	db := newTestDB(t)
+	seedCatalogue(t, db)
+	repo := repository.NewGormBookRepository(db)
+
+	if err := repo.Delete(3, 0); err != nil {
+		t.Fatal(err)
+	}
+	var deleted domain.Book
+	if err := db.Unscoped().First(&deleted, 3).Error; err != nil {
+		t.Fatal(err)
+	}
+	if deleted.ISBN != "9780131103627" {
+		t.Errorf("got ISBN %q on the deleted book, want 9780131103627", deleted.ISBN)
+	}
+
+	again := &domain.Book{Title: "The Hobbit", Author: "J.R.R. Tolkien", ISBN: "9780131103627", CategoryID: 1}
+	if _, err := repo.Save(again); err != nil {
+		t.Fatalf("cataloguing the ISBN of a deleted book: %v", err)
+	}
+	twice := &domain.Book{Title: "The Hobbit", Author: "J.R.R. Tolkien", ISBN: "9780131103627", CategoryID: 1}
+	if _, err := repo.Save(twice); !errors.Is(err, domain.ErrDuplicateISBN) {
+		t.Errorf("cataloguing the ISBN of a live book: got %v, want %v", err, domain.ErrDuplicateISBN)
+	}
+}
+
+// Books with open loans or holds are not deleted, alone or with their
+// category, since their loans could not be returned any more.
+func TestGormBookRepositoryDeleteInUse(t *testing.T) {
+	db := newTestDB(t)
+	seedCatalogue(t, db)
+	books := repository.NewGormBookRepository(db)
+	categories := repository.NewGormCategoryRepository(db)
+	loans := repository.NewGormLoanRepository(db)
+	holds := repository.NewGormReservationRepository(db)
+	member := uuid.New()
+
+	loan := checkout(t, loans, member, 1)
+	if err := books.Delete(1, 0); !errors.Is(err, domain.ErrBookInUse) {
+		t.Errorf("deleting a book on loan: got %v, want %v", err, domain.ErrBookInUse)
+	}
+	if _, err := categories.Delete(2, domain.CategoryDeletion{Cascade: true}); !errors.Is(err, domain.ErrBookInUse) {
+		t.Errorf("cascading to a book on loan: got %v, want %v", err, domain.ErrBookInUse)
+	}
+
+	hold := &domain.Reservation{UserID: uuid.New(), BookID: 1, Status: domain.ReservationWaiting}
+	if err := holds.Place(hold); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	loan.ReturnedAt = &now
+	if err := loans.Return(loan, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := books.Delete(1, 0); !errors.Is(err, domain.ErrBookInUse) {
+		t.Errorf("deleting a book held for a member: got %v, want %v", err, domain.ErrBookInUse)
+	}
+
+	if err := holds.Close(hold, domain.ReservationCancelled); err != nil {
+		t.Fatal(err)
+	}
+	if err := books.Delete(1, 0); err != nil {
+		t.Errorf("deleting a book without loans or holds: %v", err)
+	}
+	walkIn := &domain.Loan{UserID: member, BookID: 1, BorrowedAt: now, DueAt: now}
+	if err := loans.Checkout(walkIn); !errors.Is(err, domain.ErrBookNotFound) {
+		t.Errorf("checking out a deleted book: got %v, want %v", err, domain.ErrBookNotFound)
+	}
+}
@@ -1,25 +1,176 @@
 package repository
 
 import (
+	"slices"
+
 	"github.com/abushaista/lms-backend/internal/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// categorySubtreeSQL selects the ids of one or more categories and of every
+// category below them. UNION, unlike UNION ALL, stops on rows already seen.
+const categorySubtreeSQL = `WITH RECURSIVE subtree (id) AS (
+	SELECT id FROM categories WHERE id IN (?) AND deleted_at IS NULL
+	UNION
+	SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id WHERE c.deleted_at IS NULL
+) SELECT id FROM subtree`
+
 type GormCategoryRepository struct {
 	db *gorm.DB
 }
 
 // Delete implements domain.CategoryRepository.
-func (g *GormCategoryRepository) Delete(id uint) error {
-	return g.db.Delete(&domain.Category{}, id).Error
+func (g *GormCategoryRepository) Delete(id uint, d domain.CategoryDeletion) ([]int64, error) {
+	var bookIDs []int64
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		// the lock also holds back books being added to the category meanwhile
+		var category domain.Category
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&category, id).Error
+		if err != nil {
+			return dbError(err, domain.ErrCategoryNotFound)
+		}
+		if d.Version != 0 && d.Version != category.Version {
+			return domain.ErrVersionMismatch
+		}
+		if d.ReassignTo != 0 {
+			var target int64
+			if err := tx.Model(&domain.Category{}).Where("id = ?", d.ReassignTo).Count(&target).Error; err != nil {
+				return err
+			}
+			if target == 0 || d.ReassignTo == id {
+				return domain.ErrInvalidReassign
+			}
+		}
+		err = tx.Model(&domain.Book{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("category_id = ?", id).Order("id").Pluck("id", &bookIDs).Error
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case len(bookIDs) == 0:
+		case d.ReassignTo != 0:
+			err = tx.Model(&domain.Book{}).Where("category_id = ?", id).Updates(map[string]interface{}{
+				"category_id": d.ReassignTo,
+				"version":     gorm.Expr("version + 1"),
+			}).Error
+		case d.Cascade:
+			// as in GormBookRepository.Delete
+			var inUse bool
+			if inUse, err = booksInUse(tx, bookIDs); err == nil && inUse {
+				return domain.ErrBookInUse
+			}
+			if err == nil {
+				err = tx.Where("category_id = ?", id).Delete(&domain.Book{}).Error
+			}
+		default:
+			return &domain.CategoryInUseError{Books: int64(len(bookIDs))}
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := reparentCategories(tx, id, category.ParentID); err != nil {
+			return err
+		}
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bookIDs, nil
+}
+
+// Merge implements domain.CategoryRepository.
+func (g *GormCategoryRepository) Merge(merges []*domain.CategoryMerge) ([]int64, error) {
+	var bookIDs []int64
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		for _, m := range merges {
+			var categories []domain.Category
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id IN ?", []uint{m.TargetID, m.SourceID}).Find(&categories).Error
+			if err != nil {
+				return err
+			}
+			if len(categories) != 2 {
+				return domain.ErrCategoryNotFound
+			}
+			var subtree []uint
+			if err := tx.Raw(categorySubtreeSQL, m.SourceID).Scan(&subtree).Error; err != nil {
+				return err
+			}
+			if slices.Contains(subtree, m.TargetID) {
+				return domain.ErrCategoryCycle
+			}
+			for _, c := range categories {
+				if c.ID == m.SourceID {
+					m.SourceName = c.Name
+				}
+			}
+
+			var moved []int64
+			if err := tx.Model(&domain.Book{}).Where("category_id = ?", m.SourceID).Pluck("id", &moved).Error; err != nil {
+				return err
+			}
+			err = tx.Model(&domain.Book{}).Where("category_id = ?", m.SourceID).Updates(map[string]interface{}{
+				"category_id": m.TargetID,
+				"version":     gorm.Expr("version + 1"),
+			}).Error
+			if err != nil {
+				return err
+			}
+			if err := reparentCategories(tx, m.SourceID, &m.TargetID); err != nil {
+				return err
+			}
+			if err := tx.Delete(&domain.Category{}, m.SourceID).Error; err != nil {
+				return err
+			}
+			m.BooksMoved = int64(len(moved))
+			if err := tx.Create(m).Error; err != nil {
+				return err
+			}
+			bookIDs = append(bookIDs, moved...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bookIDs, nil
+}
+
+// GetMerges implements domain.CategoryRepository.
+func (g *GormCategoryRepository) GetMerges(targetID uint) ([]*domain.CategoryMerge, error) {
+	var merges []*domain.CategoryMerge
+	if err := g.db.Where("target_id = ?", targetID).Order("id DESC").Find(&merges).Error; err != nil {
+		return nil, err
+	}
+	return merges, nil
 }
 
 // Create implements domain.CategoryRepository.
 func (g *GormCategoryRepository) Save(category *domain.Category) error {
-	if category.ID != 0 {
-		return g.db.Save(category).Error
-	}
-	return g.db.Create(category).Error
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkCategoryParent(tx, category.ID, category.ParentID); err != nil {
+			return err
+		}
+		if category.ID == 0 {
+			category.Version = 1
+			return tx.Create(category).Error
+		}
+		var current domain.Category
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, category.ID).Error; err != nil {
+			return err
+		}
+		if category.Version != 0 && category.Version != current.Version {
+			return domain.ErrVersionMismatch
+		}
+		category.Version = current.Version + 1
+		category.CreatedAt = current.CreatedAt
+		return tx.Save(category).Error
+	})
+	return dbError(err, domain.ErrCategoryNotFound)
 }
 
 // GetAll implements domain.CategoryRepository.
@@ -31,33 +182,116 @@ func (g *GormCategoryRepository) GetAll() ([]*domain.Category, error) {
 	return categories, nil
 }
 
+// categoryKeyset maps domain.CategorySortFields to columns.
+var categoryKeyset = keyset[*domain.Category]{
+	columns: map[string]sortColumn[*domain.Category]{
+		"id":   {"categories.id", func(c *domain.Category) any { return c.ID }},
+		"name": {"categories.name", func(c *domain.Category) any { return c.Name }},
+	},
+	id: sortColumn[*domain.Category]{"categories.id", func(c *domain.Category) any { return c.ID }},
+}
+
 // GetByFilterAll implements domain.CategoryRepository.
-func (g *GormCategoryRepository) GetByFilterAll(page int, limit int, filter string) ([]*domain.Category, int64, error) {
-	var categories []*domain.Category
-	var total int64
+func (g *GormCategoryRepository) GetByFilterAll(filter string, q domain.PageQuery) (*domain.Page[*domain.Category], error) {
 	query := g.db.Model(&domain.Category{})
 	if filter != "" {
-		query.Where("name LIKE ?", "%"+filter+"%")
-	}
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-	offset := (page - 1) * limit
-	if err := query.Limit(limit).Offset(offset).Find(&categories).Error; err != nil {
-		return nil, 0, err
+		query = query.Where(containsCondition(query, "name"), "%"+filter+"%")
 	}
-	return categories, total, nil
+	return categoryKeyset.page(query, q)
 }
 
 // GetByID implements domain.CategoryRepository.
 func (g *GormCategoryRepository) GetByID(id uint) (*domain.Category, error) {
 	var category domain.Category
 	if err := g.db.First(&category, id).Error; err != nil {
-		return nil, err
+		return nil, dbError(err, domain.ErrCategoryNotFound)
 	}
 	return &category, nil
 }
 
+// GetByName implements domain.CategoryRepository.
+func (g *GormCategoryRepository) GetByName(name string) (*domain.Category, error) {
+	var category domain.Category
+	if err := g.db.Where("name = ?", name).First(&category).Error; err != nil {
+		return nil, dbError(err, domain.ErrCategoryNotFound)
+	}
+	return &category, nil
+}
+
+// GetDescendantIDs implements domain.CategoryRepository.
+func (g *GormCategoryRepository) GetDescendantIDs(id uint) ([]uint, error) {
+	var ids []uint
+	if err := g.db.Raw(categorySubtreeSQL, id).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// SetParent implements domain.CategoryRepository.
+func (g *GormCategoryRepository) SetParent(id uint, parentID *uint) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkCategoryParent(tx, id, parentID); err != nil {
+			return err
+		}
+		res := tx.Model(&domain.Category{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"parent_id": parentID,
+			"version":   gorm.Expr("version + 1"),
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return domain.ErrCategoryNotFound
+		}
+		return nil
+	})
+}
+
+// checkCategoryParent locks category id, unless it is new, and parentID, so
+// that neither moves or goes away before the transaction ends, then checks
+// that parentID exists and is not id or below it.
+func checkCategoryParent(tx *gorm.DB, id uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	if id != 0 && *parentID == id {
+		return domain.ErrCategoryCycle
+	}
+	ids := []uint{*parentID}
+	if id != 0 {
+		ids = append(ids, id)
+	}
+	// in id order, so two moves of the same pair cannot deadlock
+	var locked []uint
+	err := tx.Model(&domain.Category{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).Order("id").Pluck("id", &locked).Error
+	if err != nil {
+		return err
+	}
+	if len(locked) != len(ids) {
+		return domain.ErrCategoryNotFound
+	}
+	if id == 0 {
+		return nil
+	}
+	var subtree []uint
+	if err := tx.Raw(categorySubtreeSQL, id).Scan(&subtree).Error; err != nil {
+		return err
+	}
+	if slices.Contains(subtree, *parentID) {
+		return domain.ErrCategoryCycle
+	}
+	return nil
+}
+
+// reparentCategories moves the children of a category under parentID.
+func reparentCategories(tx *gorm.DB, id uint, parentID *uint) error {
+	return tx.Model(&domain.Category{}).Where("parent_id = ?", id).Updates(map[string]interface{}{
+		"parent_id": parentID,
+		"version":   gorm.Expr("version + 1"),
+	}).Error
+}
+
 func NewGormCategoryRepository(db *gorm.DB) domain.CategoryRepository {
 	return &GormCategoryRepository{db: db}
 }
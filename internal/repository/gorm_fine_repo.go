@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormFineRepository struct {
+	db *gorm.DB
+}
+
+// GetByID implements domain.FineRepository.
+func (g *GormFineRepository) GetByID(id int64) (*domain.Fine, error) {
+	var fine domain.Fine
+	if err := g.db.Preload("Transactions").First(&fine, id).Error; err != nil {
+		return nil, dbError(err, domain.ErrFineNotFound)
+	}
+	return &fine, nil
+}
+
+// GetByUser implements domain.FineRepository.
+func (g *GormFineRepository) GetByUser(userID uuid.UUID) ([]*domain.Fine, error) {
+	var fines []*domain.Fine
+	err := g.db.Preload("Transactions").
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Find(&fines).Error
+	return fines, err
+}
+
+// OutstandingBalance implements domain.FineRepository.
+func (g *GormFineRepository) OutstandingBalance(userID uuid.UUID) (int64, error) {
+	var balance int64
+	err := g.db.Model(&domain.Fine{}).
+		Select("COALESCE(SUM(amount - paid - waived), 0)").
+		Where("user_id = ?", userID).
+		Scan(&balance).Error
+	return balance, err
+}
+
+// Apply implements domain.FineRepository.
+func (g *GormFineRepository) Apply(t *domain.FineTransaction) (*domain.Fine, error) {
+	var fine domain.Fine
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&fine, t.FineID).Error; err != nil {
+			return dbError(err, domain.ErrFineNotFound)
+		}
+		if t.Amount > fine.Outstanding {
+			return domain.ErrOverpayment
+		}
+
+		column := "paid"
+		if t.Kind == domain.FineWaiver {
+			column = "waived"
+		}
+		if err := tx.Model(&fine).Update(column, gorm.Expr(column+" + ?", t.Amount)).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(t).Error; err != nil {
+			return err
+		}
+		fine = domain.Fine{}
+		return tx.Preload("Transactions").First(&fine, t.FineID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &fine, nil
+}
+
+func NewGormFineRepository(db *gorm.DB) domain.FineRepository {
+	return &GormFineRepository{db: db}
+}
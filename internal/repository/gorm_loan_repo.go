@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormLoanRepository struct {
+	db *gorm.DB
+}
+
+// Checkout implements domain.LoanRepository.
+func (g *GormLoanRepository) Checkout(l *domain.Loan) error {
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		// holds back a deletion of the book until the loan is in
+		if err := lockBook(tx, l.BookID); err != nil {
+			return err
+		}
+		bookCopy, err := pickCopy(tx, l)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(bookCopy).Update("status", domain.CopyOnLoan).Error; err != nil {
+			return err
+		}
+		l.CopyID = bookCopy.ID
+		return tx.Create(l).Error
+	})
+	return dbError(err, domain.ErrLoanNotFound)
+}
+
+// Return implements domain.LoanRepository.
+func (g *GormLoanRepository) Return(l *domain.Loan, fine *domain.Fine) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBook(tx, l.BookID); err != nil {
+			return err
+		}
+		res := tx.Model(&domain.Loan{}).
+			Where("id = ? AND returned_at IS NULL", l.ID).
+			Update("returned_at", l.ReturnedAt)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return domain.ErrLoanReturned
+		}
+		if fine != nil {
+			if err := tx.Create(fine).Error; err != nil {
+				return err
+			}
+		}
+		return releaseCopy(tx, l.BookID, l.CopyID, domain.CopyOnLoan, *l.ReturnedAt)
+	})
+}
+
+// pickCopy locks the copy to lend. A copy set aside for a ready hold of the
+// borrower takes precedence and fulfils the hold; otherwise the first copy on
+// the shelf is taken so concurrent checkouts cannot pick the same item.
+func pickCopy(tx *gorm.DB, l *domain.Loan) (*domain.BookCopy, error) {
+	var bookCopy domain.BookCopy
+	var hold domain.Reservation
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND book_id = ? AND status = ?", l.UserID, l.BookID, domain.ReservationReady).
+		First(&hold).Error
+	switch {
+	case err == nil:
+		if err := tx.Model(&hold).Update("status", domain.ReservationFulfilled).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bookCopy, hold.CopyID).Error; err != nil {
+			return nil, err
+		}
+		return &bookCopy, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("book_id = ? AND status = ?", l.BookID, domain.CopyAvailable).
+		Order("id").
+		First(&bookCopy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrBookUnavailable
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bookCopy, nil
+}
+
+// Renew implements domain.LoanRepository.
+func (g *GormLoanRepository) Renew(l *domain.Loan, dueAt time.Time) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBook(tx, l.BookID); err != nil {
+			return err
+		}
+		var waiting int64
+		err := tx.Model(&domain.Reservation{}).
+			Where("book_id = ? AND status = ?", l.BookID, domain.ReservationWaiting).
+			Count(&waiting).Error
+		if err != nil {
+			return err
+		}
+		if waiting > 0 {
+			return domain.ErrHoldsWaiting
+		}
+
+		res := tx.Model(&domain.Loan{}).
+			Where("id = ? AND returned_at IS NULL AND renewals = ?", l.ID, l.Renewals).
+			Updates(map[string]interface{}{"due_at": dueAt, "renewals": l.Renewals + 1})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			var current domain.Loan
+			if err := tx.First(&current, l.ID).Error; err != nil {
+				return dbError(err, domain.ErrLoanNotFound)
+			}
+			if !current.IsOpen() {
+				return domain.ErrLoanReturned
+			}
+			return domain.ErrLoanChanged
+		}
+		l.DueAt = dueAt
+		l.Renewals++
+		return nil
+	})
+}
+
+// lockBook locks the row of a book, which serializes the changes to its hold
+// queue with the renewals of its loans and the release of its copies.
+func lockBook(tx *gorm.DB, bookID int64) error {
+	var ids []int64
+	err := tx.Model(&domain.Book{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", bookID).Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return domain.ErrBookNotFound
+	}
+	return nil
+}
+
+// booksInUse reports whether any of the books has an open loan or a hold
+// waiting or ready. Lock the books first so none is added meanwhile.
+func booksInUse(tx *gorm.DB, bookIDs []int64) (bool, error) {
+	var loans, holds int64
+	err := tx.Model(&domain.Loan{}).Where("book_id IN ? AND returned_at IS NULL", bookIDs).Count(&loans).Error
+	if err != nil || loans > 0 {
+		return loans > 0, err
+	}
+	err = tx.Model(&domain.Reservation{}).Where("book_id IN ? AND status IN ?", bookIDs,
+		[]domain.ReservationStatus{domain.ReservationWaiting, domain.ReservationReady}).Count(&holds).Error
+	return holds > 0, err
+}
+
+// GetByID implements domain.LoanRepository.
+func (g *GormLoanRepository) GetByID(id int64) (*domain.Loan, error) {
+	var loan domain.Loan
+	if err := g.db.Preload("Book", withCopyCounts).First(&loan, id).Error; err != nil {
+		return nil, dbError(err, domain.ErrLoanNotFound)
+	}
+	return &loan, nil
+}
+
+// GetByUser implements domain.LoanRepository.
+func (g *GormLoanRepository) GetByUser(userID uuid.UUID, page, limit int) ([]*domain.Loan, int64, error) {
+	query := g.db.Model(&domain.Loan{}).Where("user_id = ?", userID)
+	return paginateLoans(query, page, limit)
+}
+
+// GetOverdue implements domain.LoanRepository.
+func (g *GormLoanRepository) GetOverdue(now time.Time, page, limit int) ([]*domain.Loan, int64, error) {
+	query := g.db.Model(&domain.Loan{}).Where("returned_at IS NULL AND due_at < ?", now)
+	return paginateLoans(query, page, limit)
+}
+
+// GetOverdueByUser implements domain.LoanRepository.
+func (g *GormLoanRepository) GetOverdueByUser(userID uuid.UUID, now time.Time) ([]*domain.Loan, error) {
+	var loans []*domain.Loan
+	err := g.db.Where("user_id = ? AND returned_at IS NULL AND due_at < ?", userID, now).
+		Order("due_at").
+		Find(&loans).Error
+	return loans, err
+}
+
+func paginateLoans(query *gorm.DB, page, limit int) ([]*domain.Loan, int64, error) {
+	var loans []*domain.Loan
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * limit
+	if err := query.Preload("Book", withCopyCounts).Order("due_at").Limit(limit).Offset(offset).Find(&loans).Error; err != nil {
+		return nil, 0, err
+	}
+	return loans, total, nil
+}
+
+func NewGormLoanRepository(db *gorm.DB) domain.LoanRepository {
+	return &GormLoanRepository{db: db}
+}
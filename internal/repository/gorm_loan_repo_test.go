@@ -0,0 +1,100 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+func checkout(t *testing.T, repo domain.LoanRepository, userID uuid.UUID, bookID int64) *domain.Loan {
+	t.Helper()
+	now := time.Now()
+	loan := &domain.Loan{UserID: userID, BookID: bookID, BorrowedAt: now, DueAt: now.Add(14 * 24 * time.Hour)}
+	if err := repo.Checkout(loan); err != nil {
+		t.Fatal(err)
+	}
+	return loan
+}
+
+func TestGormLoanRepositoryRenew(t *testing.T) {
+	db := newTestDB(t)
+	seedCatalogue(t, db)
+	repo := repository.NewGormLoanRepository(db)
+	member, other := uuid.New(), uuid.New()
+
+	loan := checkout(t, repo, member, 1)
+	stale := *loan
+	dueAt := loan.DueAt.Add(14 * 24 * time.Hour)
+	if err := repo.Renew(loan, dueAt); err != nil {
+		t.Fatal(err)
+	}
+	if loan.Renewals != 1 || !loan.DueAt.Equal(dueAt) {
+		t.Errorf("got %d renewals due at %v, want 1 due at %v", loan.Renewals, loan.DueAt, dueAt)
+	}
+	if err := repo.Renew(&stale, dueAt); !errors.Is(err, domain.ErrLoanChanged) {
+		t.Errorf("renewing a loan renewed meanwhile: got %v, want %v", err, domain.ErrLoanChanged)
+	}
+
+	// a return that happened meanwhile is not undone
+	returned := *loan
+	now := time.Now()
+	returned.ReturnedAt = &now
+	if err := repo.Return(&returned, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Renew(loan, dueAt.Add(time.Hour)); !errors.Is(err, domain.ErrLoanReturned) {
+		t.Errorf("renewing a returned loan: got %v, want %v", err, domain.ErrLoanReturned)
+	}
+	if got, _ := repo.GetByID(loan.ID); got.IsOpen() {
+		t.Error("the renewal reopened a returned loan")
+	}
+
+	loan = checkout(t, repo, member, 4)
+	hold := &domain.Reservation{UserID: other, BookID: 4, Status: domain.ReservationWaiting}
+	if err := db.Create(hold).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Renew(loan, loan.DueAt.Add(time.Hour)); !errors.Is(err, domain.ErrHoldsWaiting) {
+		t.Errorf("renewing a loan with holds waiting: got %v, want %v", err, domain.ErrHoldsWaiting)
+	}
+}
+
+func TestGormLoanRepositoryReturnCharges(t *testing.T) {
+	db := newTestDB(t)
+	seedCatalogue(t, db)
+	repo := repository.NewGormLoanRepository(db)
+	fines := repository.NewGormFineRepository(db)
+	member := uuid.New()
+
+	loan := checkout(t, repo, member, 1)
+	now := time.Now()
+	if overdue, err := repo.GetOverdueByUser(member, now.Add(15*24*time.Hour)); err != nil || len(overdue) != 1 {
+		t.Errorf("got overdue loans %v, %v; want the open loan", overdue, err)
+	}
+	if overdue, _ := repo.GetOverdueByUser(member, now); len(overdue) != 0 {
+		t.Errorf("got overdue loans %v before the due date, want none", overdue)
+	}
+
+	loan.ReturnedAt = &now
+	fine := &domain.Fine{UserID: member, LoanID: loan.ID, Amount: 150}
+	if err := repo.Return(loan, fine); err != nil {
+		t.Fatal(err)
+	}
+	if balance, _ := fines.OutstandingBalance(member); balance != 150 {
+		t.Errorf("got balance %d after a late return, want 150", balance)
+	}
+	// a second return charges nothing
+	if err := repo.Return(loan, &domain.Fine{UserID: member, LoanID: loan.ID, Amount: 150}); !errors.Is(err, domain.ErrLoanReturned) {
+		t.Errorf("returning a loan twice: got %v, want %v", err, domain.ErrLoanReturned)
+	}
+	if balance, _ := fines.OutstandingBalance(member); balance != 150 {
+		t.Errorf("got balance %d after a second return, want 150", balance)
+	}
+	if overdue, _ := repo.GetOverdueByUser(member, now.Add(15*24*time.Hour)); len(overdue) != 0 {
+		t.Errorf("got overdue loans %v after the return, want none", overdue)
+	}
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GormRefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// Create implements domain.RefreshTokenRepository.
+func (g *GormRefreshTokenRepository) Create(t *domain.RefreshToken) error {
+	return dbError(g.db.Create(t).Error, domain.ErrInvalidRefreshToken)
+}
+
+// GetByHash implements domain.RefreshTokenRepository.
+func (g *GormRefreshTokenRepository) GetByHash(hash string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	if err := g.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, dbError(err, domain.ErrInvalidRefreshToken)
+	}
+	return &token, nil
+}
+
+// MarkUsed implements domain.RefreshTokenRepository.
+func (g *GormRefreshTokenRepository) MarkUsed(id uuid.UUID, at time.Time) error {
+	res := g.db.Model(&domain.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrRefreshTokenReused
+	}
+	return nil
+}
+
+// RevokeFamily implements domain.RefreshTokenRepository.
+func (g *GormRefreshTokenRepository) RevokeFamily(familyID uuid.UUID, at time.Time) error {
+	return g.db.Model(&domain.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+// IsFamilyRevoked implements domain.RefreshTokenRepository.
+func (g *GormRefreshTokenRepository) IsFamilyRevoked(familyID uuid.UUID) (bool, error) {
+	var revoked int64
+	err := g.db.Model(&domain.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NOT NULL", familyID).
+		Count(&revoked).Error
+	return revoked > 0, err
+}
+
+func NewGormRefreshTokenRepository(db *gorm.DB) domain.RefreshTokenRepository {
+	return &GormRefreshTokenRepository{db: db}
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormReservationRepository struct {
+	db *gorm.DB
+}
+
+// Place implements domain.ReservationRepository.
+func (g *GormReservationRepository) Place(r *domain.Reservation) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		// the book lock keeps concurrent holds of the user and returns of
+		// the book out until the hold is queued
+		if err := lockBook(tx, r.BookID); err != nil {
+			return err
+		}
+		var available int64
+		err := tx.Model(&domain.BookCopy{}).
+			Where("book_id = ? AND status = ?", r.BookID, domain.CopyAvailable).
+			Count(&available).Error
+		if err != nil {
+			return err
+		}
+		if available > 0 {
+			return domain.ErrBookAvailable
+		}
+		var active int64
+		err = tx.Model(&domain.Reservation{}).
+			Where("user_id = ? AND book_id = ? AND status IN ?", r.UserID, r.BookID, activeReservationStatuses).
+			Count(&active).Error
+		if err != nil {
+			return err
+		}
+		if active > 0 {
+			return domain.ErrDuplicateHold
+		}
+		return dbError(tx.Create(r).Error, domain.ErrReservationNotFound)
+	})
+}
+
+// GetByID implements domain.ReservationRepository.
+func (g *GormReservationRepository) GetByID(id int64) (*domain.Reservation, error) {
+	var reservation domain.Reservation
+	if err := g.db.Preload("Book", withCopyCounts).First(&reservation, id).Error; err != nil {
+		return nil, dbError(err, domain.ErrReservationNotFound)
+	}
+	return &reservation, nil
+}
+
+// GetByUser implements domain.ReservationRepository.
+func (g *GormReservationRepository) GetByUser(userID uuid.UUID) ([]*domain.Reservation, error) {
+	var reservations []*domain.Reservation
+	err := g.db.Preload("Book", withCopyCounts).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// GetQueue implements domain.ReservationRepository.
+func (g *GormReservationRepository) GetQueue(bookID int64) ([]*domain.Reservation, error) {
+	var reservations []*domain.Reservation
+	err := g.db.
+		Where("book_id = ? AND status IN ?", bookID, activeReservationStatuses).
+		Order("id").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// GetExpired implements domain.ReservationRepository.
+func (g *GormReservationRepository) GetExpired(now time.Time) ([]*domain.Reservation, error) {
+	var reservations []*domain.Reservation
+	err := g.db.
+		Where("status = ? AND expires_at < ?", domain.ReservationReady, now).
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// CountAhead implements domain.ReservationRepository.
+func (g *GormReservationRepository) CountAhead(r *domain.Reservation) (int64, error) {
+	var ahead int64
+	err := g.db.Model(&domain.Reservation{}).
+		Where("book_id = ? AND status = ? AND id < ?", r.BookID, domain.ReservationWaiting, r.ID).
+		Count(&ahead).Error
+	return ahead, err
+}
+
+// PromoteNext implements domain.ReservationRepository.
+func (g *GormReservationRepository) PromoteNext(bookID int64, readyAt, expiresAt time.Time) (*domain.Reservation, error) {
+	var promoted *domain.Reservation
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBook(tx, bookID); err != nil {
+			return err
+		}
+		var next domain.Reservation
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("book_id = ? AND status = ?", bookID, domain.ReservationWaiting).
+			Order("id").
+			First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var bookCopy domain.BookCopy
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("book_id = ? AND status = ?", bookID, domain.CopyAvailable).
+			Order("id").
+			First(&bookCopy).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&bookCopy).Update("status", domain.CopyOnHold).Error; err != nil {
+			return err
+		}
+		next.Status = domain.ReservationReady
+		next.CopyID = bookCopy.ID
+		next.ReadyAt = &readyAt
+		next.ExpiresAt = &expiresAt
+		if err := tx.Omit(clause.Associations).Save(&next).Error; err != nil {
+			return err
+		}
+		promoted = &next
+		return nil
+	})
+	return promoted, err
+}
+
+// Close implements domain.ReservationRepository.
+func (g *GormReservationRepository) Close(r *domain.Reservation, status domain.ReservationStatus) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBook(tx, r.BookID); err != nil {
+			return err
+		}
+		res := tx.Model(&domain.Reservation{}).
+			Where("id = ? AND status IN ?", r.ID, activeReservationStatuses).
+			Update("status", status)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return domain.ErrReservationClosed
+		}
+		r.Status = status
+		if r.CopyID == 0 {
+			return nil
+		}
+		return releaseCopy(tx, r.BookID, r.CopyID, domain.CopyOnHold, time.Now())
+	})
+}
+
+// releaseCopy hands a copy coming back from status to the oldest waiting hold
+// of its book, or else puts it back on the shelf. A copy whose status was
+// changed meanwhile, say to lost, stays as it is. The book must be locked, so
+// that no hold is queued meanwhile.
+func releaseCopy(tx *gorm.DB, bookID, copyID int64, from domain.CopyStatus, now time.Time) error {
+	var bookCopy domain.BookCopy
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bookCopy, copyID).Error; err != nil {
+		return dbError(err, domain.ErrCopyNotFound)
+	}
+	if bookCopy.Status != from {
+		return nil
+	}
+
+	var next domain.Reservation
+	err := tx.Where("book_id = ? AND status = ?", bookID, domain.ReservationWaiting).
+		Order("id").
+		First(&next).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Model(&bookCopy).Update("status", domain.CopyAvailable).Error
+	}
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(&bookCopy).Update("status", domain.CopyOnHold).Error; err != nil {
+		return err
+	}
+	return tx.Model(&next).Updates(map[string]interface{}{
+		"status":     domain.ReservationReady,
+		"copy_id":    copyID,
+		"ready_at":   now,
+		"expires_at": now.Add(domain.HoldPickupWindow),
+	}).Error
+}
+
+var activeReservationStatuses = []domain.ReservationStatus{domain.ReservationWaiting, domain.ReservationReady}
+
+func NewGormReservationRepository(db *gorm.DB) domain.ReservationRepository {
+	return &GormReservationRepository{db: db}
+}
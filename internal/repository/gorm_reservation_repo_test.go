@@ -0,0 +1,86 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+func TestGormReservationRepositoryPlace(t *testing.T) {
+	db := newTestDB(t)
+	seedCatalogue(t, db)
+	repo := repository.NewGormReservationRepository(db)
+	member := uuid.New()
+
+	if err := repo.Place(&domain.Reservation{UserID: member, BookID: 1, Status: domain.ReservationWaiting}); !errors.Is(err, domain.ErrBookAvailable) {
+		t.Errorf("holding an available book: got %v, want %v", err, domain.ErrBookAvailable)
+	}
+	if err := repo.Place(&domain.Reservation{UserID: member, BookID: 2, Status: domain.ReservationWaiting}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Place(&domain.Reservation{UserID: member, BookID: 2, Status: domain.ReservationWaiting}); !errors.Is(err, domain.ErrDuplicateHold) {
+		t.Errorf("holding a book twice: got %v, want %v", err, domain.ErrDuplicateHold)
+	}
+	if err := repo.Place(&domain.Reservation{UserID: member, BookID: 99, Status: domain.ReservationWaiting}); !errors.Is(err, domain.ErrBookNotFound) {
+		t.Errorf("holding an unknown book: got %v, want %v", err, domain.ErrBookNotFound)
+	}
+}
+
+// A returned copy goes to the head of the queue in the return itself, and on
+// to the next hold when the one it was set aside for is cancelled.
+func TestGormReservationRepositoryReleaseCopy(t *testing.T) {
+	db := newTestDB(t)
+	seedCatalogue(t, db)
+	loans := repository.NewGormLoanRepository(db)
+	holds := repository.NewGormReservationRepository(db)
+	borrower, first, second := uuid.New(), uuid.New(), uuid.New()
+
+	loan := checkout(t, loans, borrower, 1)
+	queue := []*domain.Reservation{
+		{UserID: first, BookID: 1, Status: domain.ReservationWaiting},
+		{UserID: second, BookID: 1, Status: domain.ReservationWaiting},
+	}
+	for _, r := range queue {
+		if err := holds.Place(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	loan.ReturnedAt = &now
+	if err := loans.Return(loan, nil); err != nil {
+		t.Fatal(err)
+	}
+	hold, err := holds.GetByID(queue[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hold.Status != domain.ReservationReady || hold.CopyID != loan.CopyID || hold.ExpiresAt == nil {
+		t.Errorf("got hold %s for copy %d, want ready for copy %d with a pickup deadline", hold.Status, hold.CopyID, loan.CopyID)
+	}
+	walkIn := &domain.Loan{UserID: uuid.New(), BookID: 1, BorrowedAt: now, DueAt: now}
+	if err := loans.Checkout(walkIn); !errors.Is(err, domain.ErrBookUnavailable) {
+		t.Errorf("checking out a copy set aside: got %v, want %v", err, domain.ErrBookUnavailable)
+	}
+
+	if err := holds.Close(hold, domain.ReservationCancelled); err != nil {
+		t.Fatal(err)
+	}
+	next, err := holds.GetByID(queue[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Status != domain.ReservationReady || next.CopyID != loan.CopyID {
+		t.Errorf("got next hold %s for copy %d, want ready for copy %d", next.Status, next.CopyID, loan.CopyID)
+	}
+	if err := holds.Close(next, domain.ReservationExpired); err != nil {
+		t.Fatal(err)
+	}
+	if err := loans.Checkout(walkIn); err != nil {
+		t.Errorf("checking out the copy of an expired hold: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"gorm.io/gorm"
+)
+
+type GormTagRepository struct {
+	db *gorm.DB
+}
+
+// Save implements domain.TagRepository.
+func (g *GormTagRepository) Save(tag *domain.Tag) error {
+	var err error
+	if tag.ID != 0 {
+		err = g.db.Save(tag).Error
+	} else {
+		err = g.db.Create(tag).Error
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return domain.ErrDuplicateTag
+	}
+	return dbError(err, domain.ErrTagNotFound)
+}
+
+// GetByFilterAll implements domain.TagRepository.
+func (g *GormTagRepository) GetByFilterAll(page, limit int, filter string) ([]*domain.Tag, int64, error) {
+	var tags []*domain.Tag
+	var total int64
+	query := g.db.Model(&domain.Tag{})
+	if filter != "" {
+		query = query.Where(containsCondition(query, "name"), "%"+filter+"%")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * limit
+	if err := query.Order("name").Limit(limit).Offset(offset).Find(&tags).Error; err != nil {
+		return nil, 0, err
+	}
+	return tags, total, nil
+}
+
+// GetByID implements domain.TagRepository.
+func (g *GormTagRepository) GetByID(id uint) (*domain.Tag, error) {
+	var tag domain.Tag
+	if err := g.db.First(&tag, id).Error; err != nil {
+		return nil, dbError(err, domain.ErrTagNotFound)
+	}
+	return &tag, nil
+}
+
+// Delete implements domain.TagRepository.
+func (g *GormTagRepository) Delete(id uint) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM book_tags WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(&domain.Tag{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return domain.ErrTagNotFound
+		}
+		return nil
+	})
+}
+
+func NewGormTagRepository(db *gorm.DB) domain.TagRepository {
+	return &GormTagRepository{db: db}
+}
@@ -1,9 +1,8 @@
 package repository
 
 import (
-	"errors"
-
 	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -15,7 +14,7 @@ type GormUserRepository struct {
 func (g *GormUserRepository) CreateUser(u *domain.User) (string, error) {
 	err := g.db.Create(&u).Error
 	if err != nil {
-		return "", err
+		return "", dbError(err, domain.ErrUserNotFound)
 	}
 	return u.ID.String(), nil
 }
@@ -23,11 +22,31 @@ func (g *GormUserRepository) CreateUser(u *domain.User) (string, error) {
 // GetByUsername implements domain.UserRepository.
 func (g *GormUserRepository) GetByUsername(username string) (*domain.User, error) {
 	var user domain.User
-	err := g.db.Where("username = ?", username).First(&user).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, nil
+	if err := g.db.Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, dbError(err, domain.ErrUserNotFound)
+	}
+	return &user, nil
+}
+
+// GetByID implements domain.UserRepository.
+func (g *GormUserRepository) GetByID(id uuid.UUID) (*domain.User, error) {
+	var user domain.User
+	if err := g.db.Where("id = ?", id).First(&user).Error; err != nil {
+		return nil, dbError(err, domain.ErrUserNotFound)
+	}
+	return &user, nil
+}
+
+// UpdateRole implements domain.UserRepository.
+func (g *GormUserRepository) UpdateRole(id uuid.UUID, role domain.Role) error {
+	res := g.db.Model(&domain.User{}).Where("id = ?", id).Update("role", role)
+	if res.Error != nil {
+		return dbError(res.Error, domain.ErrUserNotFound)
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrUserNotFound
 	}
-	return &user, err
+	return nil
 }
 
 func NewGormUserRepository(db *gorm.DB) domain.UserRepository {
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+)
+
+// MemoryBookCopyRepository is a domain.BookCopyRepository over a
+// MemoryStore. The copies count towards the availability of their books.
+type MemoryBookCopyRepository struct {
+	s *MemoryStore
+}
+
+// Save implements domain.BookCopyRepository.
+func (m *MemoryBookCopyRepository) Save(c *domain.BookCopy) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	current, exists := m.s.copies[c.ID]
+	if c.ID != 0 && !exists {
+		return domain.ErrCopyNotFound
+	}
+	for _, other := range m.s.copies {
+		if other.Barcode == c.Barcode && other.ID != c.ID {
+			return domain.ErrBarcodeTaken
+		}
+	}
+
+	now := time.Now()
+	if exists {
+		if c.Status != "" && c.Status != current.Status {
+			if err := current.Released(); err != nil {
+				return err
+			}
+		}
+		if c.Status == "" {
+			c.Status = current.Status
+		}
+		c.CreatedAt = current.CreatedAt
+	} else {
+		m.s.lastCopyID++
+		c.ID, c.CreatedAt = m.s.lastCopyID, now
+	}
+	if c.Status == "" {
+		c.Status = domain.CopyAvailable
+	}
+	c.UpdatedAt = now
+	m.s.copies[c.ID] = *c
+	return nil
+}
+
+// GetByID implements domain.BookCopyRepository.
+func (m *MemoryBookCopyRepository) GetByID(id int64) (*domain.BookCopy, error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	c, ok := m.s.copies[id]
+	if !ok {
+		return nil, domain.ErrCopyNotFound
+	}
+	return &c, nil
+}
+
+// GetByBarcode implements domain.BookCopyRepository.
+func (m *MemoryBookCopyRepository) GetByBarcode(barcode string) (*domain.BookCopy, error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	for _, c := range m.s.copies {
+		if c.Barcode == barcode {
+			return &c, nil
+		}
+	}
+	return nil, domain.ErrCopyNotFound
+}
+
+// GetByBook implements domain.BookCopyRepository.
+func (m *MemoryBookCopyRepository) GetByBook(bookID int64) ([]*domain.BookCopy, error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	copies := []*domain.BookCopy{}
+	for _, c := range m.s.copies {
+		if c.BookID == bookID {
+			copies = append(copies, &c)
+		}
+	}
+	slices.SortFunc(copies, func(a, b *domain.BookCopy) int { return cmp.Compare(a.ID, b.ID) })
+	return copies, nil
+}
+
+// Delete implements domain.BookCopyRepository.
+func (m *MemoryBookCopyRepository) Delete(id int64) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	if _, ok := m.s.copies[id]; !ok {
+		return domain.ErrCopyNotFound
+	}
+	delete(m.s.copies, id)
+	return nil
+}
+
+func NewMemoryBookCopyRepository(s *MemoryStore) domain.BookCopyRepository {
+	return &MemoryBookCopyRepository{s: s}
+}
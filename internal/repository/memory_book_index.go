@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+)
+
+// MemoryBookIndex is an in-process domain.BookSearchIndex for tests and for
+// running without Elasticsearch. It mirrors the ranking rules of
+// ElasticBookIndex: weighted fields, fuzzy terms and facets before filtering.
+type MemoryBookIndex struct {
+	mu   sync.RWMutex
+	docs map[int64]bookDocument
+}
+
+var memoryFieldBoosts = []struct {
+	name  string
+	boost float64
+	text  func(d bookDocument) string
+}{
+	{"isbn", 4, func(d bookDocument) string { return d.ISBN }},
+	{"title", 3, func(d bookDocument) string { return d.Title }},
+	{"author", 2, func(d bookDocument) string { return d.Author }},
+	{"summary", 1, func(d bookDocument) string { return d.Summary }},
+}
+
+// Index implements domain.BookSearchIndex.
+func (m *MemoryBookIndex) Index(b *domain.Book) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.docs[b.ID] = newBookDocument(b)
+	return nil
+}
+
+// Remove implements domain.BookSearchIndex.
+func (m *MemoryBookIndex) Remove(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.docs, id)
+	return nil
+}
+
+// Search implements domain.BookSearchIndex.
+func (m *MemoryBookIndex) Search(q domain.BookSearchQuery) (*domain.BookSearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	terms := tokenize(q.Query)
+	categories := map[uint]*domain.FacetBucket{}
+	years := map[int]*domain.FacetBucket{}
+	var hits []domain.BookSearchHit
+
+	for _, d := range m.docs {
+		score, highlights := scoreDocument(d, terms)
+		if len(terms) > 0 && score == 0 {
+			continue
+		}
+		if len(terms) == 0 {
+			score = 1
+		}
+
+		if categories[d.CategoryID] == nil {
+			categories[d.CategoryID] = &domain.FacetBucket{Key: strconv.FormatUint(uint64(d.CategoryID), 10), Label: d.CategoryName}
+		}
+		categories[d.CategoryID].Count++
+		if years[d.Year] == nil {
+			years[d.Year] = &domain.FacetBucket{Key: strconv.Itoa(d.Year)}
+		}
+		years[d.Year].Count++
+
+		if (q.Category != 0 && d.CategoryID != q.Category) || (q.Year != 0 && d.Year != q.Year) {
+			continue
+		}
+		hits = append(hits, domain.BookSearchHit{BookID: d.ID, Score: score, Highlights: highlights})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].BookID < hits[j].BookID
+	})
+
+	result := &domain.BookSearchResult{
+		Total:  int64(len(hits)),
+		Facets: map[string][]domain.FacetBucket{},
+	}
+	start := (q.Page - 1) * q.Limit
+	end := start + q.Limit
+	if start > len(hits) {
+		start = len(hits)
+	}
+	if end > len(hits) {
+		end = len(hits)
+	}
+	result.Hits = hits[start:end]
+
+	for _, b := range categories {
+		result.Facets["category"] = append(result.Facets["category"], *b)
+	}
+	sort.Slice(result.Facets["category"], func(i, j int) bool {
+		a, b := result.Facets["category"][i], result.Facets["category"][j]
+		return a.Count > b.Count || (a.Count == b.Count && a.Key < b.Key)
+	})
+	for _, b := range years {
+		result.Facets["year"] = append(result.Facets["year"], *b)
+	}
+	sort.Slice(result.Facets["year"], func(i, j int) bool {
+		return result.Facets["year"][i].Key > result.Facets["year"][j].Key
+	})
+	return result, nil
+}
+
+// scoreDocument sums the boosts of every field token matching a query term
+// and wraps the matching tokens in <em> for highlighting.
+func scoreDocument(d bookDocument, terms []string) (float64, map[string][]string) {
+	var score float64
+	var highlights map[string][]string
+	for _, f := range memoryFieldBoosts {
+		words := strings.Fields(f.text(d))
+		matched := false
+		for i, w := range words {
+			token := strings.Join(tokenize(w), "")
+			for _, t := range terms {
+				if fuzzyMatch(t, token) {
+					score += f.boost
+					words[i] = "<em>" + w + "</em>"
+					matched = true
+					break
+				}
+			}
+		}
+		if matched && f.name != "isbn" {
+			if highlights == nil {
+				highlights = map[string][]string{}
+			}
+			highlights[f.name] = []string{strings.Join(words, " ")}
+		}
+	}
+	return score, highlights
+}
+
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// fuzzyMatch follows the Elasticsearch AUTO fuzziness: exact for terms of up
+// to two characters, one edit up to five and two edits beyond.
+func fuzzyMatch(term, token string) bool {
+	if token == "" {
+		return false
+	}
+	allowed := 2
+	switch n := len([]rune(term)); {
+	case n <= 2:
+		allowed = 0
+	case n <= 5:
+		allowed = 1
+	}
+	return levenshtein(term, token) <= allowed
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func NewMemoryBookIndex() *MemoryBookIndex {
+	return &MemoryBookIndex{docs: map[int64]bookDocument{}}
+}
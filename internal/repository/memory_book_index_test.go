@@ -0,0 +1,115 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/repository"
+)
+
+func newMemoryIndex(t *testing.T) *repository.MemoryBookIndex {
+	t.Helper()
+	index := repository.NewMemoryBookIndex()
+	fiction := domain.Category{ID: 1, Name: "Fiction"}
+	science := domain.Category{ID: 2, Name: "Science"}
+	for _, b := range []*domain.Book{
+		{ID: 1, Title: "The Garden", Author: "Ann Moss", Year: 1990, CategoryID: 1, Category: fiction},
+		{ID: 2, Title: "Weeds", Author: "Tom Garden", Year: 1990, CategoryID: 2, Category: science},
+		{ID: 3, Title: "Soil", Author: "Ann Moss", Year: 2001, CategoryID: 2, Category: science, Summary: "How a garden grows"},
+		{ID: 4, Title: "Rivers", Author: "Lee Stone", Year: 2001, CategoryID: 1, Category: fiction, ISBN: "9780306406157"},
+	} {
+		if err := index.Index(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return index
+}
+
+func hitIDs(r *domain.BookSearchResult) []int64 {
+	ids := make([]int64, 0, len(r.Hits))
+	for _, h := range r.Hits {
+		ids = append(ids, h.BookID)
+	}
+	return ids
+}
+
+func TestMemoryBookIndexRanking(t *testing.T) {
+	index := newMemoryIndex(t)
+
+	// title before author before summary
+	result, err := index.Search(domain.BookSearchQuery{Query: "garden", Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(result); len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("got hits %v, want [1 2 3]", got)
+	}
+	if got := result.Hits[0].Highlights["title"]; len(got) != 1 || got[0] != "The <em>Garden</em>" {
+		t.Errorf("got title highlights %v", got)
+	}
+
+	result, _ = index.Search(domain.BookSearchQuery{Query: "9780306406157", Page: 1, Limit: 10})
+	if got := hitIDs(result); len(got) != 1 || got[0] != 4 {
+		t.Errorf("got hits %v for an ISBN, want [4]", got)
+	}
+
+	result, _ = index.Search(domain.BookSearchQuery{Query: "garden", Page: 2, Limit: 2})
+	if got := hitIDs(result); result.Total != 3 || len(got) != 1 || got[0] != 3 {
+		t.Errorf("got hits %v of %d on the second page, want [3] of 3", got, result.Total)
+	}
+}
+
+func TestMemoryBookIndexFuzziness(t *testing.T) {
+	index := newMemoryIndex(t)
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"gardn", 3},  // one edit
+		{"gardne", 3}, // two edits on a long term
+		{"mos", 2},    // one edit on a short term
+		{"soi", 1},
+		{"sol", 1},
+		{"ma", 0},     // two letters must match exactly
+		{"rivxyz", 0}, // three edits
+	}
+	for _, tt := range tests {
+		result, err := index.Search(domain.BookSearchQuery{Query: tt.query, Page: 1, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int(result.Total) != tt.want {
+			t.Errorf("%q: got %d hits %v, want %d", tt.query, result.Total, hitIDs(result), tt.want)
+		}
+	}
+}
+
+func TestMemoryBookIndexFacets(t *testing.T) {
+	index := newMemoryIndex(t)
+
+	result, err := index.Search(domain.BookSearchQuery{Query: "garden", Category: 2, Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(result); len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Errorf("got hits %v in category 2, want [2 3]", got)
+	}
+	// the facets count the hits before the category filter
+	categories := result.Facets["category"]
+	if len(categories) != 2 || categories[0] != (domain.FacetBucket{Key: "2", Label: "Science", Count: 2}) ||
+		categories[1] != (domain.FacetBucket{Key: "1", Label: "Fiction", Count: 1}) {
+		t.Errorf("got category facets %+v", categories)
+	}
+	years := result.Facets["year"]
+	if len(years) != 2 || years[0] != (domain.FacetBucket{Key: "2001", Count: 1}) || years[1] != (domain.FacetBucket{Key: "1990", Count: 2}) {
+		t.Errorf("got year facets %+v", years)
+	}
+
+	if err := index.Remove(3); err != nil {
+		t.Fatal(err)
+	}
+	result, _ = index.Search(domain.BookSearchQuery{Year: 2001, Page: 1, Limit: 10})
+	if got := hitIDs(result); len(got) != 1 || got[0] != 4 {
+		t.Errorf("got hits %v from 2001 after a removal, want [4]", got)
+	}
+}
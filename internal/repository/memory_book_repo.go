@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+)
+
+// MemoryBookRepository is a domain.BookRepository over a MemoryStore.
+type MemoryBookRepository struct {
+	s *MemoryStore
+}
+
+// Save implements domain.BookRepository.
+func (m *MemoryBookRepository) Save(b *domain.Book) (int64, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	// check everything before creating authors, which would otherwise
+	// outlive a failed save
+	for _, a := range b.Authors {
+		if _, ok := m.s.authors[a.ID]; a.ID != 0 && !ok {
+			return b.ID, domain.ErrAuthorNotFound
+		}
+	}
+	for _, t := range b.Tags {
+		if _, ok := m.s.tags[t.ID]; !ok {
+			return b.ID, domain.ErrTagNotFound
+		}
+	}
+	for _, other := range m.s.books {
+		if other.ISBN == b.ISBN && other.ID != b.ID {
+			return b.ID, domain.ErrDuplicateISBN
+		}
+	}
+	current, exists := m.s.books[b.ID]
+	if b.ID != 0 && !exists {
+		return b.ID, domain.ErrBookNotFound
+	}
+	if exists && b.Version != 0 && b.Version != current.Version {
+		return b.ID, domain.ErrVersionMismatch
+	}
+
+	for i, a := range b.Authors {
+		if a.ID != 0 {
+			b.Authors[i] = m.s.authors[a.ID]
+			continue
+		}
+		b.Authors[i] = m.findOrCreateAuthor(a.Name)
+	}
+	if b.Author == "" {
+		b.Author = domain.JoinAuthors(b.Authors)
+	}
+	for i, t := range b.Tags {
+		b.Tags[i] = m.s.tags[t.ID]
+	}
+
+	// timestamps given to a new book are kept, as gorm does
+	now := time.Now()
+	if exists {
+		b.Version = current.Version + 1
+		if b.CreatedAt.IsZero() {
+			b.CreatedAt = current.CreatedAt
+		}
+		b.UpdatedAt = now
+	} else {
+		m.s.lastBookID++
+		b.ID, b.Version = m.s.lastBookID, 1
+		b.CreatedAt, b.UpdatedAt = orNow(b.CreatedAt, now), orNow(b.UpdatedAt, now)
+	}
+
+	stored := *b
+	stored.Category = domain.Category{}
+	stored.Authors = make([]domain.Author, len(b.Authors))
+	for i, a := range b.Authors {
+		stored.Authors[i].ID = a.ID
+	}
+	// without Tags the book keeps its links, as in the GORM repository
+	stored.Tags = current.Tags
+	if b.Tags != nil {
+		stored.Tags = make([]domain.Tag, len(b.Tags))
+		for i, t := range b.Tags {
+			stored.Tags[i].ID = t.ID
+		}
+	}
+	stored.TotalCopies, stored.AvailableCopies, stored.Available = 0, 0, false
+	m.s.books[b.ID] = stored
+	return b.ID, nil
+}
+
+func orNow(t, now time.Time) time.Time {
+	if t.IsZero() {
+		return now
+	}
+	return t
+}
+
+func (m *MemoryBookRepository) findOrCreateAuthor(name string) domain.Author {
+	for _, a := range m.s.authors {
+		if a.Name == name {
+			return a
+		}
+	}
+	m.s.lastAuthorID++
+	now := time.Now()
+	a := domain.Author{ID: m.s.lastAuthorID, Name: name, CreatedAt: now, UpdatedAt: now}
+	m.s.authors[a.ID] = a
+	return a
+}
+
+// GetAll implements domain.BookRepository.
+func (m *MemoryBookRepository) GetAll(filter domain.BookFilter, q domain.PageQuery) (*domain.Page[*domain.Book], error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	return bookKeyset.slice(m.find(filter), q)
+}
+
+// find loads the books passing filter, in no particular order.
+func (m *MemoryBookRepository) find(filter domain.BookFilter) []*domain.Book {
+	if filter.IncludeSubcategories {
+		filter.Categories = m.s.subtree(filter.Categories...)
+		if len(filter.Categories) == 0 {
+			return nil
+		}
+	}
+	var books []*domain.Book
+	for _, b := range m.s.books {
+		if book := m.s.loadBook(b); matchBook(book, filter) {
+			books = append(books, book)
+		}
+	}
+	return books
+}
+
+// GetByID implements domain.BookRepository.
+func (m *MemoryBookRepository) GetByID(id int64) (*domain.Book, error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	b, ok := m.s.books[id]
+	if !ok {
+		return nil, domain.ErrBookNotFound
+	}
+	return m.s.loadBook(b), nil
+}
+
+// GetByISBN implements domain.BookRepository.
+func (m *MemoryBookRepository) GetByISBN(isbn string) (*domain.Book, error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	for _, b := range m.s.books {
+		if b.ISBN == isbn {
+			return m.s.loadBook(b), nil
+		}
+	}
+	return nil, domain.ErrBookNotFound
+}
+
+// Stream implements domain.BookRepository. The books are loaded at once, in
+// id order, and fn is called without holding the store, so it may use the
+// repositories; batch only matters to databases.
+func (m *MemoryBookRepository) Stream(filter domain.BookFilter, batch int, fn func(b *domain.Book) error) error {
+	m.s.mu.RLock()
+	books := m.find(filter)
+	m.s.mu.RUnlock()
+
+	slices.SortFunc(books, func(a, b *domain.Book) int { return cmp.Compare(a.ID, b.ID) })
+	for _, b := range books {
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetCover implements domain.BookRepository.
+func (m *MemoryBookRepository) SetCover(id int64, key, url string) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	b, ok := m.s.books[id]
+	if !ok {
+		return domain.ErrBookNotFound
+	}
+	b.CoverImageKey, b.CoverImageURL = key, url
+	b.Version++
+	b.UpdatedAt = time.Now()
+	m.s.books[id] = b
+	return nil
+}
+
+// Delete implements domain.BookRepository.
+func (m *MemoryBookRepository) Delete(id, version int64) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	b, ok := m.s.books[id]
+	if !ok {
+		return domain.ErrBookNotFound
+	}
+	if version != 0 && version != b.Version {
+		return domain.ErrVersionMismatch
+	}
+	delete(m.s.books, id)
+	return nil
+}
+
+func NewMemoryBookRepository(s *MemoryStore) domain.BookRepository {
+	return &MemoryBookRepository{s: s}
+}
@@ -0,0 +1,44 @@
+package repository_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/repository"
+)
+
+func TestMemoryBookRepositoryConcurrentSaves(t *testing.T) {
+	s := repository.NewMemoryStore()
+	books := repository.NewMemoryBookRepository(s)
+	categories := repository.NewMemoryCategoryRepository(s)
+	if err := categories.Save(&domain.Category{Name: "Fiction"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b := &domain.Book{Title: fmt.Sprintf("Book %d", i), Authors: []domain.Author{{Name: "Anonymous"}},
+				ISBN: fmt.Sprintf("isbn-%d", i), CategoryID: 1}
+			if _, err := books.Save(b); err != nil {
+				t.Error(err)
+			}
+			if _, err := books.GetAll(domain.BookFilter{Title: "Book"}, domain.PageQuery{Page: 1, Limit: 5}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	page, err := books.GetAll(domain.BookFilter{AuthorID: 1}, domain.PageQuery{Page: 1, Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 50 {
+		t.Errorf("got %d books by the shared author, want 50", page.Total)
+	}
+}
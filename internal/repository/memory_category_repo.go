@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+)
+
+// MemoryCategoryRepository is a domain.CategoryRepository over a
+// MemoryStore.
+type MemoryCategoryRepository struct {
+	s *MemoryStore
+}
+
+// Save implements domain.CategoryRepository.
+func (m *MemoryCategoryRepository) Save(category *domain.Category) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	current, exists := m.s.categories[category.ID]
+	if category.ID != 0 && !exists {
+		return domain.ErrCategoryNotFound
+	}
+	if exists && category.Version != 0 && category.Version != current.Version {
+		return domain.ErrVersionMismatch
+	}
+	if err := m.checkParent(category.ID, category.ParentID); err != nil {
+		return err
+	}
+	for _, other := range m.s.categories {
+		if other.Name == category.Name && other.ID != category.ID {
+			return fmt.Errorf("%w: category name %q is taken", domain.ErrConflict, category.Name)
+		}
+	}
+
+	now := time.Now()
+	if exists {
+		category.Version = current.Version + 1
+		category.CreatedAt, category.UpdatedAt = current.CreatedAt, now
+	} else {
+		m.s.lastCategoryID++
+		category.ID, category.Version = m.s.lastCategoryID, 1
+		category.CreatedAt, category.UpdatedAt = orNow(category.CreatedAt, now), orNow(category.UpdatedAt, now)
+	}
+
+	stored := *category
+	stored.Children, stored.Books = nil, nil
+	m.s.categories[category.ID] = stored
+	return nil
+}
+
+// GetAll implements domain.CategoryRepository.
+func (m *MemoryCategoryRepository) GetAll() ([]*domain.Category, error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	categories := m.find("")
+	slices.SortFunc(categories, func(a, b *domain.Category) int { return cmp.Compare(a.ID, b.ID) })
+	return categories, nil
+}
+
+// find returns the categories whose name contains filter regardless of case,
+// in no particular order.
+func (m *MemoryCategoryRepository) find(filter string) []*domain.Category {
+	categories := []*domain.Category{}
+	for _, c := range m.s.categories {
+		if strings.Contains(strings.ToLower(c.Name), strings.ToLower(filter)) {
+			categories = append(categories, &c)
+		}
+	}
+	return categories
+}
+
+// GetByFilterAll implements domain.CategoryRepository.
+func (m *MemoryCategoryRepository) GetByFilterAll(filter string, q domain.PageQuery) (*domain.Page[*domain.Category], error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	return categoryKeyset.slice(m.find(filter), q)
+}
+
+// GetByID implements domain.CategoryRepository.
+func (m *MemoryCategoryRepository) GetByID(id uint) (*domain.Category, error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	c, ok := m.s.categories[id]
+	if !ok {
+		return nil, domain.ErrCategoryNotFound
+	}
+	return &c, nil
+}
+
+// GetByName implements domain.CategoryRepository.
+func (m *MemoryCategoryRepository) GetByName(name string) (*domain.Category, error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	for _, c := range m.s.categories {
+		if c.Name == name {
+			return &c, nil
+		}
+	}
+	return nil, domain.ErrCategoryNotFound
+}
+
+// GetDescendantIDs implements domain.CategoryRepository.
+func (m *MemoryCategoryRepository) GetDescendantIDs(id uint) ([]uint, error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	return m.s.subtree(id), nil
+}
+
+// SetParent implements domain.CategoryRepository.
+func (m *MemoryCategoryRepository) SetParent(id uint, parentID *uint) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	c, ok := m.s.categories[id]
+	if !ok {
+		return domain.ErrCategoryNotFound
+	}
+	if err := m.checkParent(id, parentID); err != nil {
+		return err
+	}
+	c.ParentID = parentID
+	c.Version++
+	c.UpdatedAt = time.Now()
+	m.s.categories[id] = c
+	return nil
+}
+
+// Delete implements domain.CategoryRepository.
+func (m *MemoryCategoryRepository) Delete(id uint, d domain.CategoryDeletion) ([]int64, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	category, ok := m.s.categories[id]
+	if !ok {
+		return nil, domain.ErrCategoryNotFound
+	}
+	if d.Version != 0 && d.Version != category.Version {
+		return nil, domain.ErrVersionMismatch
+	}
+	if d.ReassignTo != 0 {
+		if _, ok := m.s.categories[d.ReassignTo]; !ok || d.ReassignTo == id {
+			return nil, domain.ErrInvalidReassign
+		}
+	}
+	bookIDs := m.bookIDs(id)
+
+	switch {
+	case len(bookIDs) == 0:
+	case d.ReassignTo != 0:
+		m.moveBooks(bookIDs, d.ReassignTo)
+	case d.Cascade:
+		for _, bookID := range bookIDs {
+			delete(m.s.books, bookID)
+		}
+	default:
+		return nil, &domain.CategoryInUseError{Books: int64(len(bookIDs))}
+	}
+
+	m.reparent(id, category.ParentID)
+	delete(m.s.categories, id)
+	return bookIDs, nil
+}
+
+// Merge implements domain.CategoryRepository.
+func (m *MemoryCategoryRepository) Merge(merges []*domain.CategoryMerge) ([]int64, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	snap := m.s.snapshot()
+	var bookIDs []int64
+	for _, merge := range merges {
+		source, ok := m.s.categories[merge.SourceID]
+		if _, found := m.s.categories[merge.TargetID]; !ok || !found {
+			m.s.restore(snap)
+			return nil, domain.ErrCategoryNotFound
+		}
+		if slices.Contains(m.s.subtree(merge.SourceID), merge.TargetID) {
+			m.s.restore(snap)
+			return nil, domain.ErrCategoryCycle
+		}
+		merge.SourceName = source.Name
+
+		moved := m.bookIDs(merge.SourceID)
+		m.moveBooks(moved, merge.TargetID)
+		target := merge.TargetID
+		m.reparent(merge.SourceID, &target)
+		delete(m.s.categories, merge.SourceID)
+
+		m.s.lastMergeID++
+		merge.ID, merge.BooksMoved, merge.CreatedAt = m.s.lastMergeID, int64(len(moved)), time.Now()
+		m.s.merges = append(m.s.merges, *merge)
+		bookIDs = append(bookIDs, moved...)
+	}
+	return bookIDs, nil
+}
+
+// GetMerges implements domain.CategoryRepository.
+func (m *MemoryCategoryRepository) GetMerges(targetID uint) ([]*domain.CategoryMerge, error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	merges := []*domain.CategoryMerge{}
+	for i := len(m.s.merges) - 1; i >= 0; i-- {
+		if merge := m.s.merges[i]; merge.TargetID == targetID {
+			merges = append(merges, &merge)
+		}
+	}
+	return merges, nil
+}
+
+// checkParent checks that parentID exists and is not category id or below it.
+func (m *MemoryCategoryRepository) checkParent(id uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	if _, ok := m.s.categories[*parentID]; !ok {
+		return domain.ErrCategoryNotFound
+	}
+	if *parentID == id || (id != 0 && slices.Contains(m.s.subtree(id), *parentID)) {
+		return domain.ErrCategoryCycle
+	}
+	return nil
+}
+
+// bookIDs returns the ids of the books of a category, in id order.
+func (m *MemoryCategoryRepository) bookIDs(id uint) []int64 {
+	var ids []int64
+	for _, b := range m.s.books {
+		if b.CategoryID == id {
+			ids = append(ids, b.ID)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (m *MemoryCategoryRepository) moveBooks(ids []int64, categoryID uint) {
+	for _, id := range ids {
+		b := m.s.books[id]
+		b.CategoryID = categoryID
+		b.Version++
+		m.s.books[id] = b
+	}
+}
+
+// reparent moves the children of a category under parentID.
+func (m *MemoryCategoryRepository) reparent(id uint, parentID *uint) {
+	for _, c := range m.s.categories {
+		if c.ParentID != nil && *c.ParentID == id {
+			c.ParentID = parentID
+			c.Version++
+			m.s.categories[c.ID] = c
+		}
+	}
+}
+
+func NewMemoryCategoryRepository(s *MemoryStore) domain.CategoryRepository {
+	return &MemoryCategoryRepository{s: s}
+}
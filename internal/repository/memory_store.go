@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// MemoryStore holds the data of the in-memory repositories, for tests and
+// for demos without a database. Repositories made from one store see each
+// other's data as if they shared a database: deleting a category moves or
+// deletes its books. Rows are kept by value and replaced rather than
+// modified, so that a snapshot of the maps can undo a failed write.
+//
+// The store knows no loans or holds: the status of a copy only changes when
+// the copy is saved.
+type MemoryStore struct {
+	mu sync.RWMutex
+
+	// books hold their authors and tags by id only and no category
+	books      map[int64]domain.Book
+	categories map[uint]domain.Category
+	authors    map[uint]domain.Author
+	tags       map[uint]domain.Tag
+	copies     map[int64]domain.BookCopy
+	merges     []domain.CategoryMerge
+	users      map[uuid.UUID]domain.User
+
+	lastBookID     int64
+	lastCategoryID uint
+	lastAuthorID   uint
+	lastTagID      uint
+	lastCopyID     int64
+	lastMergeID    int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		books:      map[int64]domain.Book{},
+		categories: map[uint]domain.Category{},
+		authors:    map[uint]domain.Author{},
+		tags:       map[uint]domain.Tag{},
+		copies:     map[int64]domain.BookCopy{},
+		users:      map[uuid.UUID]domain.User{},
+	}
+}
+
+// memorySnapshot is the state of the catalogue before a write spanning
+// several rows.
+type memorySnapshot struct {
+	books      map[int64]domain.Book
+	categories map[uint]domain.Category
+	merges     int
+	lastMerge  int64
+}
+
+func (s *MemoryStore) snapshot() memorySnapshot {
+	return memorySnapshot{maps.Clone(s.books), maps.Clone(s.categories), len(s.merges), s.lastMergeID}
+}
+
+func (s *MemoryStore) restore(snap memorySnapshot) {
+	s.books, s.categories = snap.books, snap.categories
+	s.merges, s.lastMergeID = s.merges[:snap.merges], snap.lastMerge
+}
+
+// loadBook returns a book as the GORM repository loads it, with its
+// category, authors, tags and copy counts.
+func (s *MemoryStore) loadBook(b domain.Book) *domain.Book {
+	b.Category = s.categories[b.CategoryID]
+	authors := make([]domain.Author, 0, len(b.Authors))
+	for _, a := range b.Authors {
+		authors = append(authors, s.authors[a.ID])
+	}
+	b.Authors = authors
+	tags := make([]domain.Tag, 0, len(b.Tags))
+	for _, t := range b.Tags {
+		tags = append(tags, s.tags[t.ID])
+	}
+	b.Tags = tags
+	for _, c := range s.copies {
+		if c.BookID != b.ID {
+			continue
+		}
+		b.TotalCopies++
+		if c.Status == domain.CopyAvailable {
+			b.AvailableCopies++
+		}
+	}
+	b.Available = b.AvailableCopies > 0
+	return &b
+}
+
+// subtree returns the ids of the given categories and of every category
+// below them.
+func (s *MemoryStore) subtree(ids ...uint) []uint {
+	var subtree []uint
+	for _, id := range ids {
+		if _, ok := s.categories[id]; ok && !slices.Contains(subtree, id) {
+			subtree = append(subtree, id)
+		}
+	}
+	for i := 0; i < len(subtree); i++ {
+		for _, c := range s.categories {
+			if c.ParentID != nil && *c.ParentID == subtree[i] && !slices.Contains(subtree, c.ID) {
+				subtree = append(subtree, c.ID)
+			}
+		}
+	}
+	return subtree
+}
+
+// matchBook reports whether b passes filter, whose categories are already
+// widened to their subcategories when asked to. Substrings match regardless
+// of case, as in the GORM repositories.
+func matchBook(b *domain.Book, filter domain.BookFilter) bool {
+	contains := func(s, substr string) bool {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	}
+	switch {
+	case filter.Title != "" && !contains(b.Title, filter.Title),
+		filter.Author != "" && !contains(b.Author, filter.Author),
+		filter.Summary != "" && !contains(b.Summary, filter.Summary),
+		filter.ISBN != "" && b.ISBN != filter.ISBN,
+		len(filter.Categories) > 0 && !slices.Contains(filter.Categories, b.CategoryID),
+		filter.Year != 0 && b.Year != filter.Year,
+		filter.YearFrom != 0 && b.Year < filter.YearFrom,
+		filter.YearTo != 0 && b.Year > filter.YearTo,
+		filter.AuthorID != 0 && !slices.ContainsFunc(b.Authors, func(a domain.Author) bool { return a.ID == filter.AuthorID }),
+		filter.TagID != 0 && !slices.ContainsFunc(b.Tags, func(t domain.Tag) bool { return t.ID == filter.TagID }),
+		filter.Available != nil && b.Available != *filter.Available,
+		!filter.CreatedFrom.IsZero() && b.CreatedAt.Before(filter.CreatedFrom),
+		!filter.CreatedTo.IsZero() && b.CreatedAt.After(filter.CreatedTo),
+		!filter.UpdatedFrom.IsZero() && b.UpdatedAt.Before(filter.UpdatedFrom),
+		!filter.UpdatedTo.IsZero() && b.UpdatedAt.After(filter.UpdatedTo):
+		return false
+	}
+	return true
+}
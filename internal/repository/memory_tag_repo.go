@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+)
+
+// MemoryTagRepository is a domain.TagRepository over a MemoryStore.
+type MemoryTagRepository struct {
+	s *MemoryStore
+}
+
+// Save implements domain.TagRepository.
+func (m *MemoryTagRepository) Save(tag *domain.Tag) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	current, exists := m.s.tags[tag.ID]
+	if tag.ID != 0 && !exists {
+		return domain.ErrTagNotFound
+	}
+	for _, other := range m.s.tags {
+		if other.Name == tag.Name && other.ID != tag.ID {
+			return domain.ErrDuplicateTag
+		}
+	}
+
+	now := time.Now()
+	if exists {
+		tag.CreatedAt = current.CreatedAt
+	} else {
+		m.s.lastTagID++
+		tag.ID, tag.CreatedAt = m.s.lastTagID, now
+	}
+	tag.UpdatedAt = now
+	m.s.tags[tag.ID] = *tag
+	return nil
+}
+
+// GetByFilterAll implements domain.TagRepository.
+func (m *MemoryTagRepository) GetByFilterAll(page, limit int, filter string) ([]*domain.Tag, int64, error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	tags := []*domain.Tag{}
+	for _, t := range m.s.tags {
+		if strings.Contains(strings.ToLower(t.Name), strings.ToLower(filter)) {
+			tags = append(tags, &t)
+		}
+	}
+	slices.SortFunc(tags, func(a, b *domain.Tag) int { return cmp.Compare(a.Name, b.Name) })
+	start := min(max(page-1, 0)*limit, len(tags))
+	return tags[start:min(start+limit, len(tags))], int64(len(tags)), nil
+}
+
+// GetByID implements domain.TagRepository.
+func (m *MemoryTagRepository) GetByID(id uint) (*domain.Tag, error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	t, ok := m.s.tags[id]
+	if !ok {
+		return nil, domain.ErrTagNotFound
+	}
+	return &t, nil
+}
+
+// Delete implements domain.TagRepository. The tag is unlinked from its books.
+func (m *MemoryTagRepository) Delete(id uint) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	if _, ok := m.s.tags[id]; !ok {
+		return domain.ErrTagNotFound
+	}
+	for bookID, b := range m.s.books {
+		if slices.ContainsFunc(b.Tags, func(t domain.Tag) bool { return t.ID == id }) {
+			b.Tags = slices.DeleteFunc(slices.Clone(b.Tags), func(t domain.Tag) bool { return t.ID == id })
+			m.s.books[bookID] = b
+		}
+	}
+	delete(m.s.tags, id)
+	return nil
+}
+
+func NewMemoryTagRepository(s *MemoryStore) domain.TagRepository {
+	return &MemoryTagRepository{s: s}
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// MemoryUserRepository is a domain.UserRepository over a MemoryStore.
+type MemoryUserRepository struct {
+	s *MemoryStore
+}
+
+// CreateUser implements domain.UserRepository.
+func (m *MemoryUserRepository) CreateUser(u *domain.User) (string, error) {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	for _, other := range m.s.users {
+		if other.Username == u.Username {
+			return "", domain.ErrUsernameTaken
+		}
+	}
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	if _, ok := m.s.users[u.ID]; ok {
+		return "", domain.ErrConflict
+	}
+	if u.Role == "" {
+		u.Role = domain.RoleMember
+	}
+	now := time.Now()
+	u.CreatedAt, u.UpdatedAt = now, now
+	m.s.users[u.ID] = *u
+	return u.ID.String(), nil
+}
+
+// GetByUsername implements domain.UserRepository.
+func (m *MemoryUserRepository) GetByUsername(username string) (*domain.User, error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	for _, u := range m.s.users {
+		if u.Username == username {
+			return &u, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+// GetByID implements domain.UserRepository.
+func (m *MemoryUserRepository) GetByID(id uuid.UUID) (*domain.User, error) {
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+	u, ok := m.s.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return &u, nil
+}
+
+// UpdateRole implements domain.UserRepository.
+func (m *MemoryUserRepository) UpdateRole(id uuid.UUID, role domain.Role) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	u, ok := m.s.users[id]
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	u.Role = role
+	u.UpdatedAt = time.Now()
+	m.s.users[id] = u
+	return nil
+}
+
+func NewMemoryUserRepository(s *MemoryStore) domain.UserRepository {
+	return &MemoryUserRepository{s: s}
+}
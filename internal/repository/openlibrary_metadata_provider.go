@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+)
+
+var yearPattern = regexp.MustCompile(`\b\d{4}\b`)
+
+// OpenLibraryProvider is a domain.MetadataProvider for the Open Library
+// books API (GET /api/books?bibkeys=ISBN:...&jscmd=data) or a compatible one.
+type OpenLibraryProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+type openLibraryText struct {
+	Value string
+}
+
+// UnmarshalJSON accepts both a plain string and {"type": ..., "value": ...}.
+func (t *openLibraryText) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &t.Value); err == nil {
+		return nil
+	}
+	var typed struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return err
+	}
+	t.Value = typed.Value
+	return nil
+}
+
+type openLibraryBook struct {
+	Title       string `json:"title"`
+	Subtitle    string `json:"subtitle"`
+	PublishDate string `json:"publish_date"`
+	Authors     []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	Notes    openLibraryText `json:"notes"`
+	Excerpts []struct {
+		Text string `json:"text"`
+	} `json:"excerpts"`
+	Cover struct {
+		Large  string `json:"large"`
+		Medium string `json:"medium"`
+	} `json:"cover"`
+}
+
+// Lookup implements domain.MetadataProvider.
+func (p *OpenLibraryProvider) Lookup(isbn string) (*domain.BookMetadata, error) {
+	key := "ISBN:" + isbn
+	query := url.Values{"bibkeys": {key}, "format": {"json"}, "jscmd": {"data"}}
+	resp, err := p.client.Get(p.baseURL + "/api/books?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrMetadataUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", domain.ErrMetadataUnavailable, resp.StatusCode)
+	}
+
+	var books map[string]openLibraryBook
+	if err := json.NewDecoder(resp.Body).Decode(&books); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrMetadataUnavailable, err)
+	}
+	book, ok := books[key]
+	if !ok {
+		return nil, domain.ErrMetadataNotFound
+	}
+
+	meta := &domain.BookMetadata{
+		ISBN:          isbn,
+		Title:         book.Title,
+		Summary:       book.Notes.Value,
+		CoverImageURL: book.Cover.Large,
+	}
+	if book.Subtitle != "" {
+		meta.Title += ": " + book.Subtitle
+	}
+	names := make([]string, 0, len(book.Authors))
+	for _, a := range book.Authors {
+		names = append(names, a.Name)
+	}
+	meta.Author = strings.Join(names, "; ")
+	if y := yearPattern.FindString(book.PublishDate); y != "" {
+		meta.Year, _ = strconv.Atoi(y)
+	}
+	if meta.Summary == "" && len(book.Excerpts) > 0 {
+		meta.Summary = book.Excerpts[0].Text
+	}
+	if meta.CoverImageURL == "" {
+		meta.CoverImageURL = book.Cover.Medium
+	}
+	return meta, nil
+}
+
+// NewOpenLibraryProvider builds a provider for baseURL; a nil client uses a
+// default one with a short timeout.
+func NewOpenLibraryProvider(baseURL string, client *http.Client) *OpenLibraryProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &OpenLibraryProvider{baseURL: strings.TrimRight(baseURL, "/"), client: client}
+}
@@ -0,0 +1,97 @@
+package repository_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/repository"
+)
+
+// openLibraryServer answers every request with status and body, and records
+// the query of the last one.
+func openLibraryServer(t *testing.T, status int, body string) (*repository.OpenLibraryProvider, *string) {
+	t.Helper()
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/books" {
+			t.Errorf("got path %s, want /api/books", r.URL.Path)
+		}
+		query = r.URL.RawQuery
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return repository.NewOpenLibraryProvider(srv.URL+"/", nil), &query
+}
+
+func TestOpenLibraryProviderLookup(t *testing.T) {
+	provider, query := openLibraryServer(t, http.StatusOK, `{"ISBN:9780306406157": {
+		"title": "Dune",
+		"subtitle": "Deluxe Edition",
+		"publish_date": "August 1, 1965",
+		"authors": [{"name": "Frank Herbert"}, {"name": "Brian Herbert"}],
+		"notes": "A desert planet.",
+		"cover": {"large": "https://covers.example/l.jpg", "medium": "https://covers.example/m.jpg"}
+	}}`)
+
+	meta, err := provider.Lookup("9780306406157")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *query != "bibkeys=ISBN%3A9780306406157&format=json&jscmd=data" {
+		t.Errorf("got query %s", *query)
+	}
+	want := domain.BookMetadata{
+		ISBN:          "9780306406157",
+		Title:         "Dune: Deluxe Edition",
+		Author:        "Frank Herbert; Brian Herbert",
+		Year:          1965,
+		Summary:       "A desert planet.",
+		CoverImageURL: "https://covers.example/l.jpg",
+	}
+	if *meta != want {
+		t.Errorf("got %+v, want %+v", *meta, want)
+	}
+}
+
+func TestOpenLibraryProviderLookupTypedNotes(t *testing.T) {
+	provider, _ := openLibraryServer(t, http.StatusOK, `{"ISBN:9780306406157": {
+		"title": "Dune",
+		"notes": {"type": "/type/text", "value": "A desert planet."},
+		"cover": {"medium": "https://covers.example/m.jpg"}
+	}}`)
+
+	meta, err := provider.Lookup("9780306406157")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Summary != "A desert planet." || meta.CoverImageURL != "https://covers.example/m.jpg" {
+		t.Errorf("got summary %q and cover %q", meta.Summary, meta.CoverImageURL)
+	}
+}
+
+func TestOpenLibraryProviderLookupFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"not found", http.StatusOK, `{}`, domain.ErrMetadataNotFound},
+		{"server error", http.StatusBadGateway, `bad gateway`, domain.ErrMetadataUnavailable},
+		{"rate limited", http.StatusTooManyRequests, ``, domain.ErrMetadataUnavailable},
+		{"malformed body", http.StatusOK, `<html>`, domain.ErrMetadataUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, _ := openLibraryServer(t, tt.status, tt.body)
+			if _, err := provider.Lookup("9780306406157"); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
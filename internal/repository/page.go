@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"gorm.io/gorm"
+)
+
+// sortColumn is a column a list can be sorted by, with the way to read its
+// value back from a loaded row when building a cursor.
+type sortColumn[T any] struct {
+	name  string
+	value func(T) any
+}
+
+// keyset pages the rows of a table by the columns of their sort fields,
+// named as in domain and including "id", followed by the id column unless
+// the sort already includes it.
+type keyset[T any] struct {
+	columns map[string]sortColumn[T]
+	id      sortColumn[T]
+}
+
+// keys returns the columns q is sorted by and whether each is descending.
+func (k keyset[T]) keys(q domain.PageQuery) ([]sortColumn[T], []bool, error) {
+	keys := make([]sortColumn[T], 0, len(q.Sort)+1)
+	desc := make([]bool, 0, len(q.Sort)+1)
+	for _, s := range q.Sort {
+		col, ok := k.columns[s.Field]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s", domain.ErrInvalidSort, s.Field)
+		}
+		keys = append(keys, col)
+		desc = append(desc, s.Desc)
+	}
+	if !slices.ContainsFunc(keys, func(col sortColumn[T]) bool { return col.name == k.id.name }) {
+		keys = append(keys, k.id)
+		desc = append(desc, false)
+	}
+	if q.Cursor != nil && len(q.Cursor.Values) != len(keys) {
+		return nil, nil, domain.ErrInvalidCursor
+	}
+	if q.Cursor != nil {
+		row := zeroRow[T]()
+		for i, col := range keys {
+			if !sameKind(col.value(row), q.Cursor.Values[i]) {
+				return nil, nil, domain.ErrInvalidCursor
+			}
+		}
+	}
+	return keys, desc, nil
+}
+
+// zeroRow returns an empty row to read the types of the sort values from,
+// allocating it when T is a pointer.
+func zeroRow[T any]() T {
+	var row T
+	if t := reflect.TypeFor[T](); t.Kind() == reflect.Pointer {
+		row = reflect.New(t.Elem()).Interface().(T)
+	}
+	return row
+}
+
+// sameKind reports whether the cursor value v can stand for the sort value
+// want: both strings or both numbers, whatever their type.
+func sameKind(want, v any) bool {
+	if _, ok := want.(string); ok {
+		_, ok = v.(string)
+		return ok
+	}
+	return isNumber(v)
+}
+
+func isNumber(v any) bool {
+	switch v.(type) {
+	case int, int64, uint, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// page loads the page of query selected by q. scopes apply to loading the
+// rows but not to counting them.
+func (k keyset[T]) page(query *gorm.DB, q domain.PageQuery, scopes ...func(*gorm.DB) *gorm.DB) (*domain.Page[T], error) {
+	keys, desc, err := k.keys(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	backward := q.Cursor != nil && q.Cursor.Before
+	find := query.Session(&gorm.Session{}).Scopes(scopes...)
+	for i, col := range keys {
+		if desc[i] != backward {
+			find = find.Order(col.name + " DESC")
+		} else {
+			find = find.Order(col.name)
+		}
+	}
+	switch {
+	case q.Cursor != nil:
+		cond, args := keysetCondition(keys, desc, q.Cursor.Values, backward)
+		find = find.Where(cond, args...)
+	case q.Page > 1:
+		find = find.Offset((q.Page - 1) * q.Limit)
+	}
+
+	var items []T
+	if err := find.Limit(q.Limit + 1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return newPage(items, total, q, keys), nil
+}
+
+// slice pages items held in memory the way page pages a table. items are
+// reordered in place.
+func (k keyset[T]) slice(items []T, q domain.PageQuery) (*domain.Page[T], error) {
+	keys, desc, err := k.keys(q)
+	if err != nil {
+		return nil, err
+	}
+	backward := q.Cursor != nil && q.Cursor.Before
+	values := func(item T) []any {
+		v := make([]any, len(keys))
+		for i, col := range keys {
+			v[i] = col.value(item)
+		}
+		return v
+	}
+	// compare orders a before b in the direction the page is read
+	compare := func(a, b []any) int {
+		for i := range keys {
+			c := compareValues(a[i], b[i])
+			if desc[i] != backward {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+	slices.SortFunc(items, func(a, b T) int { return compare(values(a), values(b)) })
+
+	start := 0
+	switch {
+	case q.Cursor != nil:
+		start = len(items)
+		for i, item := range items {
+			if compare(values(item), q.Cursor.Values) > 0 {
+				start = i
+				break
+			}
+		}
+	case q.Page > 1:
+		start = min((q.Page-1)*q.Limit, len(items))
+	}
+	page := slices.Clone(items[start:min(start+q.Limit+1, len(items))])
+	return newPage(page, int64(len(items)), q, keys), nil
+}
+
+// newPage makes the page of items, read in the direction of q with one item
+// more than the limit when there is one.
+func newPage[T any](items []T, total int64, q domain.PageQuery, keys []sortColumn[T]) *domain.Page[T] {
+	backward := q.Cursor != nil && q.Cursor.Before
+	more := len(items) > q.Limit
+	if more {
+		items = items[:q.Limit]
+	}
+	if backward {
+		slices.Reverse(items)
+	}
+
+	p := &domain.Page[T]{Items: items, Total: total}
+	if len(items) == 0 {
+		return p
+	}
+	cursor := func(item T, before bool) *domain.Cursor {
+		values := make([]any, len(keys))
+		for i, col := range keys {
+			values[i] = col.value(item)
+		}
+		return &domain.Cursor{Values: values, Before: before}
+	}
+	// a page reached backward has the page it came from after it; one
+	// reached forward has items before it unless it is the first
+	if more || backward {
+		p.NextCursor = cursor(items[len(items)-1], false)
+	}
+	if backward && more || !backward && (q.Cursor != nil || q.Page > 1) {
+		p.PrevCursor = cursor(items[0], true)
+	}
+	return p
+}
+
+// compareValues compares two sort values, both strings or both numbers of
+// any type; cursors that went through JSON hold their numbers as float64.
+// Strings compare bytewise, as in SQLite, whereas MySQL collations may
+// ignore case.
+func compareValues(a, b any) int {
+	if as, ok := a.(string); ok {
+		bs, _ := b.(string)
+		return strings.Compare(as, bs)
+	}
+	return cmp.Compare(toFloat(a), toFloat(b))
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint:
+		return float64(n)
+	case float64:
+		return n
+	default:
+		return 0
+	}
+}
+
+// keysetCondition selects the rows after values in the order of keys, or
+// before them when backward is set:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+func keysetCondition[T any](keys []sortColumn[T], desc []bool, values []any, backward bool) (string, []any) {
+	ors := make([]string, len(keys))
+	var args []any
+	for i, col := range keys {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, keys[j].name+" = ?")
+			args = append(args, values[j])
+		}
+		op := " > ?"
+		if desc[i] != backward {
+			op = " < ?"
+		}
+		ands = append(ands, col.name+op)
+		args = append(args, values[i])
+		ors[i] = "(" + strings.Join(ands, " AND ") + ")"
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
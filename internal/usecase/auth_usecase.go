@@ -1,6 +1,10 @@
 package usecase
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -13,26 +17,32 @@ import (
 )
 
 type AuthUseCase struct {
-	repo      domain.UserRepository
-	validator *validator.Validate
-	jwtSecret string
+	repo       domain.UserRepository
+	tokens     domain.RefreshTokenRepository
+	validator  *validator.Validate
+	jwtSecret  string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
 }
 
-func NewAuthUseCase(r domain.UserRepository, secret string) *AuthUseCase {
+func NewAuthUseCase(r domain.UserRepository, tokens domain.RefreshTokenRepository, secret string, accessTTL, refreshTTL time.Duration) *AuthUseCase {
 	return &AuthUseCase{
-		repo:      r,
-		validator: validator.New(),
-		jwtSecret: secret,
+		repo:       r,
+		tokens:     tokens,
+		validator:  validator.New(),
+		jwtSecret:  secret,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
 	}
 }
 
 func (uc *AuthUseCase) Create(req dto.CreateUserRequest) (*domain.User, error) {
-	existing, err := uc.repo.GetByUsername(req.Username)
-	if err != nil {
-		return nil, err
+	_, err := uc.repo.GetByUsername(req.Username)
+	if err == nil {
+		return nil, domain.ErrUsernameTaken
 	}
-	if existing != nil {
-		return nil, errors.New("username already exists")
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
 	}
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -41,6 +51,7 @@ func (uc *AuthUseCase) Create(req dto.CreateUserRequest) (*domain.User, error) {
 	u := &domain.User{
 		Username: req.Username,
 		Password: string(hash),
+		Role:     domain.RoleMember,
 	}
 	id := uuid.New()
 	u.ID = id
@@ -51,23 +62,166 @@ func (uc *AuthUseCase) Create(req dto.CreateUserRequest) (*domain.User, error) {
 	return u, nil
 }
 
-func (uc *AuthUseCase) Login(req dto.LoginRequest) (string, error) {
+func (uc *AuthUseCase) Login(req dto.LoginRequest) (*domain.TokenPair, error) {
 	user, err := uc.repo.GetByUsername(req.Username)
-	if err != nil {
-		return "", err
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.ErrInvalidCredentials
 	}
-	if user == nil {
-		return "", errors.New("invalid username or password")
+	if err != nil {
+		return nil, err
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return "", errors.New("invalid username or password")
+		return nil, domain.ErrInvalidCredentials
+	}
+	return uc.issue(user, uuid.New())
+}
+
+// Refresh rotates a refresh token into a new token pair of the same session.
+// Presenting a token that was already rotated revokes the whole session.
+func (uc *AuthUseCase) Refresh(req dto.RefreshTokenRequest) (*domain.TokenPair, error) {
+	token, err := uc.tokens.GetByHash(hashToken(req.RefreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if token.RevokedAt != nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	now := time.Now()
+	if token.UsedAt != nil {
+		return nil, uc.revokeReused(token.FamilyID, now)
+	}
+	if now.After(token.ExpiresAt) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if err := uc.tokens.MarkUsed(token.ID, now); err != nil {
+		if errors.Is(err, domain.ErrRefreshTokenReused) {
+			return nil, uc.revokeReused(token.FamilyID, now)
+		}
+		return nil, err
+	}
+
+	user, err := uc.repo.GetByID(token.UserID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
 	}
+	return uc.issue(user, token.FamilyID)
+}
 
+// Logout revokes the session the refresh token belongs to, which also
+// invalidates every access token issued for it.
+func (uc *AuthUseCase) Logout(req dto.RefreshTokenRequest) error {
+	token, err := uc.tokens.GetByHash(hashToken(req.RefreshToken))
+	if err != nil {
+		return err
+	}
+	return uc.tokens.RevokeFamily(token.FamilyID, time.Now())
+}
+
+// ParseAccessToken validates an access token and rejects it when its session
+// has been revoked. It is used as the echojwt ParseTokenFunc.
+func (uc *AuthUseCase) ParseAccessToken(raw string) (*jwt.Token, error) {
+	token, err := jwt.Parse(raw, func(t *jwt.Token) (interface{}, error) {
+		return []byte(uc.jwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid claims")
+	}
+	sid, _ := claims["sid"].(string)
+	familyID, err := uuid.Parse(sid)
+	if err != nil {
+		return nil, domain.ErrTokenRevoked
+	}
+	revoked, err := uc.tokens.IsFamilyRevoked(familyID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, domain.ErrTokenRevoked
+	}
+	return token, nil
+}
+
+func (uc *AuthUseCase) revokeReused(familyID uuid.UUID, now time.Time) error {
+	if err := uc.tokens.RevokeFamily(familyID, now); err != nil {
+		return err
+	}
+	return domain.ErrRefreshTokenReused
+}
+
+// issue signs a short-lived access token and persists a new refresh token,
+// both bound to the session identified by familyID.
+func (uc *AuthUseCase) issue(user *domain.User, familyID uuid.UUID) (*domain.TokenPair, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id":  user.ID.String(),
 		"username": user.Username,
-		"exp":      time.Now().Add(time.Hour * 72).Unix(),
+		"role":     string(user.Role),
+		"sid":      familyID.String(),
+		"jti":      uuid.NewString(),
+		"iat":      now.Unix(),
+		"exp":      now.Add(uc.accessTTL).Unix(),
+	}
+	access, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(uc.jwtSecret))
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(buf)
+	err = uc.tokens.Create(&domain.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: now.Add(uc.refreshTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &domain.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(uc.accessTTL.Seconds()),
+	}, nil
+}
+
+// hashToken is what gets stored, so a leaked table cannot be replayed.
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func (uc *AuthUseCase) UpdateRole(id uuid.UUID, req dto.UpdateRoleRequest) error {
+	if err := uc.validator.Struct(req); err != nil {
+		return err
+	}
+	return uc.repo.UpdateRole(id, domain.Role(req.Role))
+}
+
+// EnsureAdmin bootstraps the admin account, creating it when it does not exist.
+func (uc *AuthUseCase) EnsureAdmin(username, password string) error {
+	existing, err := uc.repo.GetByUsername(username)
+	if errors.Is(err, domain.ErrNotFound) {
+		u, err := uc.Create(dto.CreateUserRequest{Username: username, Password: password})
+		if err != nil {
+			return err
+		}
+		existing = u
+	} else if err != nil {
+		return err
+	}
+	if existing.Role == domain.RoleAdmin {
+		return nil
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(uc.jwtSecret))
+	return uc.repo.UpdateRole(existing.ID, domain.RoleAdmin)
 }
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"strings"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/go-playground/validator/v10"
+)
+
+type AuthorUseCase struct {
+	repo      domain.AuthorRepository
+	validator *validator.Validate
+}
+
+func NewAuthorUseCase(r domain.AuthorRepository) *AuthorUseCase {
+	return &AuthorUseCase{
+		repo:      r,
+		validator: validator.New(),
+	}
+}
+
+func (uc *AuthorUseCase) Save(req dto.AuthorRequest) (*domain.Author, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := uc.validator.Struct(req); err != nil {
+		return nil, err
+	}
+
+	author := &domain.Author{Name: req.Name}
+	if req.ID != 0 {
+		existing, err := uc.repo.GetByID(req.ID)
+		if err != nil {
+			return nil, err
+		}
+		existing.Name = req.Name
+		author = existing
+	}
+	if err := uc.repo.Save(author); err != nil {
+		return nil, err
+	}
+	return author, nil
+}
+
+func (uc *AuthorUseCase) GetByID(id uint) (*domain.Author, error) {
+	return uc.repo.GetByID(id)
+}
+
+func (uc *AuthorUseCase) GetByFilterAll(page, limit int, filter string) ([]*domain.Author, int64, error) {
+	return uc.repo.GetByFilterAll(page, limit, filter)
+}
+
+func (uc *AuthorUseCase) Delete(id uint) error {
+	return uc.repo.Delete(id)
+}
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/go-playground/validator/v10"
+)
+
+type BookCopyUseCase struct {
+	repo      domain.BookCopyRepository
+	books     *BookUseCase
+	validator *validator.Validate
+}
+
+func NewBookCopyUseCase(r domain.BookCopyRepository, books *BookUseCase) *BookCopyUseCase {
+	return &BookCopyUseCase{
+		repo:      r,
+		books:     books,
+		validator: validator.New(),
+	}
+}
+
+func (uc *BookCopyUseCase) Save(req dto.BookCopyRequest) (*domain.BookCopy, error) {
+	if err := uc.validator.Struct(req); err != nil {
+		return nil, err
+	}
+	if _, err := uc.books.GetByID(req.BookID); err != nil {
+		return nil, err
+	}
+
+	bookCopy := domain.BookCopy{Status: domain.CopyAvailable}
+	if req.ID != 0 {
+		existing, err := uc.GetByID(req.BookID, req.ID)
+		if err != nil {
+			return nil, err
+		}
+		// the status of a borrowed or held copy is owned by the loan or the
+		// reservation until it is released
+		if req.Status != "" {
+			if err := existing.Released(); err != nil {
+				return nil, err
+			}
+		}
+		// an empty status keeps the stored one, whatever it is by now
+		bookCopy = domain.BookCopy{ID: existing.ID}
+	}
+
+	taken, err := uc.repo.GetByBarcode(req.Barcode)
+	switch {
+	case err == nil && taken.ID != req.ID:
+		return nil, domain.ErrBarcodeTaken
+	case err != nil && !errors.Is(err, domain.ErrNotFound):
+		return nil, err
+	}
+
+	bookCopy.BookID = req.BookID
+	bookCopy.Barcode = req.Barcode
+	bookCopy.ShelfLocation = req.ShelfLocation
+	bookCopy.Condition = req.Condition
+	if req.Status != "" {
+		bookCopy.Status = domain.CopyStatus(req.Status)
+	}
+	if err := uc.repo.Save(&bookCopy); err != nil {
+		return nil, err
+	}
+	if bookCopy.Status == domain.CopyAvailable {
+		if err := uc.books.RefreshAvailability(bookCopy.BookID); err != nil {
+			return nil, err
+		}
+	}
+	return &bookCopy, nil
+}
+
+// GetByID returns the copy only when it belongs to the given book.
+func (uc *BookCopyUseCase) GetByID(bookID, id int64) (*domain.BookCopy, error) {
+	bookCopy, err := uc.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if bookCopy.BookID != bookID {
+		return nil, domain.ErrCopyNotFound
+	}
+	return bookCopy, nil
+}
+
+func (uc *BookCopyUseCase) GetByBook(bookID int64) ([]*domain.BookCopy, error) {
+	if _, err := uc.books.GetByID(bookID); err != nil {
+		return nil, err
+	}
+	return uc.repo.GetByBook(bookID)
+}
+
+func (uc *BookCopyUseCase) Delete(bookID, id int64) error {
+	bookCopy, err := uc.GetByID(bookID, id)
+	if err != nil {
+		return err
+	}
+	if err := bookCopy.Released(); err != nil {
+		return err
+	}
+	return uc.repo.Delete(id)
+}
@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/gabriel-vasile/mimetype"
+)
+
+const (
+	// MaxCoverPixels guards against images that are small on disk but huge
+	// once decoded.
+	MaxCoverPixels = 40_000_000
+	// ThumbnailWidth is the width thumbnails are scaled down to.
+	ThumbnailWidth = 300
+)
+
+var coverExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type BookCoverUseCase struct {
+	repo     domain.BookRepository
+	storage  domain.CoverStorage
+	maxBytes int64
+}
+
+func NewBookCoverUseCase(r domain.BookRepository, storage domain.CoverStorage, maxBytes int64) *BookCoverUseCase {
+	return &BookCoverUseCase{
+		repo:     r,
+		storage:  storage,
+		maxBytes: maxBytes,
+	}
+}
+
+// Upload stores a new cover of a book together with its thumbnail and points
+// the book at it. The type is sniffed from the content, not trusted from the
+// client. Files are named after their hash, so a new cover gets a new URL.
+func (uc *BookCoverUseCase) Upload(bookID int64, r io.Reader) (*domain.Book, error) {
+	book, err := uc.repo.GetByID(bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, uc.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > uc.maxBytes {
+		return nil, domain.ErrImageTooLarge
+	}
+	ext, ok := coverExtensions[mimetype.Detect(data).String()]
+	if !ok {
+		return nil, domain.ErrUnsupportedImage
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, domain.ErrUnsupportedImage
+	}
+	if cfg.Width*cfg.Height > MaxCoverPixels {
+		return nil, domain.ErrImageTooLarge
+	}
+	thumb, err := thumbnail(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:8])
+	key := fmt.Sprintf("books/%d/%s%s", bookID, hash, ext)
+	if err := uc.storage.Put(thumbnailKey(key), thumb); err != nil {
+		return nil, err
+	}
+	if err := uc.storage.Put(key, data); err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("/api/books/%d/cover?v=%s", bookID, hash)
+	if err := uc.repo.SetCover(bookID, key, url); err != nil {
+		return nil, err
+	}
+
+	if old := book.CoverImageKey; old != "" && old != key {
+		_ = uc.storage.Delete(old)
+		_ = uc.storage.Delete(thumbnailKey(old))
+	}
+	book.CoverImageKey, book.CoverImageURL = key, url
+	return book, nil
+}
+
+// Open returns the stored cover of a book, or its thumbnail, and the ETag
+// identifying its content.
+func (uc *BookCoverUseCase) Open(bookID int64, thumb bool) (*domain.CoverObject, string, error) {
+	book, err := uc.repo.GetByID(bookID)
+	if err != nil {
+		return nil, "", err
+	}
+	if book.CoverImageKey == "" {
+		return nil, "", domain.ErrCoverNotFound
+	}
+	key := book.CoverImageKey
+	etag := strings.TrimSuffix(path.Base(key), path.Ext(key))
+	if thumb {
+		key = thumbnailKey(key)
+		etag += "-thumb"
+	}
+	obj, err := uc.storage.Open(key)
+	if err != nil {
+		return nil, "", err
+	}
+	return obj, `"` + etag + `"`, nil
+}
+
+func thumbnailKey(key string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "-thumb.jpg"
+}
+
+// thumbnail scales an image down to ThumbnailWidth, averaging the source
+// pixels behind each thumbnail pixel, and encodes it as JPEG.
+func thumbnail(data []byte) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, domain.ErrUnsupportedImage
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > ThumbnailWidth {
+		h = max(1, h*ThumbnailWidth/w)
+		w = ThumbnailWidth
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := b.Min.Y+y*b.Dy()/h, b.Min.Y+(y+1)*b.Dy()/h
+		for x := 0; x < w; x++ {
+			x0, x1 := b.Min.X+x*b.Dx()/w, b.Min.X+(x+1)*b.Dx()/w
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < max(y1, y0+1); sy++ {
+				for sx := x0; sx < max(x1, x0+1); sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a, n = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			dst.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(bl / n), uint16(a / n)})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+)
+
+const (
+	// exportBatch is the number of books loaded from the database at a time.
+	exportBatch = 500
+	// exportFlushEvery is the number of records written between flushes.
+	exportFlushEvery = 100
+)
+
+var exportContentTypes = map[string]string{
+	"csv":     "text/csv; charset=utf-8",
+	"ndjson":  "application/x-ndjson",
+	"marcxml": "application/marcxml+xml",
+}
+
+// ExportContentType returns the media type of an export format, or false
+// when the format is not supported.
+func ExportContentType(format string) (string, bool) {
+	ct, ok := exportContentTypes[format]
+	return ct, ok
+}
+
+type BookExportUseCase struct {
+	repo domain.BookRepository
+}
+
+func NewBookExportUseCase(r domain.BookRepository) *BookExportUseCase {
+	return &BookExportUseCase{repo: r}
+}
+
+// Export streams the books matching filter to w. When w can be flushed, it is
+// flushed as records are written so clients receive the data progressively.
+func (uc *BookExportUseCase) Export(w io.Writer, format string, filter domain.BookFilter) error {
+	var enc bookEncoder
+	switch format {
+	case "csv":
+		enc = newCSVBookEncoder(w)
+	case "ndjson":
+		enc = &ndjsonBookEncoder{enc: json.NewEncoder(w)}
+	case "marcxml":
+		enc = &marcBookEncoder{w: w, enc: xml.NewEncoder(w)}
+	default:
+		return domain.ErrExportFormat
+	}
+
+	if err := enc.begin(); err != nil {
+		return err
+	}
+	written := 0
+	err := uc.repo.Stream(filter, exportBatch, func(b *domain.Book) error {
+		if err := enc.encode(b); err != nil {
+			return err
+		}
+		written++
+		if written%exportFlushEvery == 0 {
+			return flush(w, enc)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := enc.end(); err != nil {
+		return err
+	}
+	return flush(w, enc)
+}
+
+type bookEncoder interface {
+	begin() error
+	encode(b *domain.Book) error
+	end() error
+	flush() error
+}
+
+func flush(w io.Writer, enc bookEncoder) error {
+	if err := enc.flush(); err != nil {
+		return err
+	}
+	if f, ok := w.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// csvBookEncoder writes the columns understood by the bulk import, followed
+// by the read-only ones.
+type csvBookEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVBookEncoder(w io.Writer) *csvBookEncoder {
+	return &csvBookEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvBookEncoder) begin() error {
+	return e.w.Write([]string{
+		"title", "author", "isbn", "year", "summary", "cover_image", "category", "category_id",
+		"id", "total_copies", "available_copies",
+	})
+}
+
+func (e *csvBookEncoder) encode(b *domain.Book) error {
+	return e.w.Write([]string{
+		b.Title, b.Author, b.ISBN, strconv.Itoa(b.Year), b.Summary, b.CoverImageURL,
+		b.Category.Name, strconv.FormatUint(uint64(b.CategoryID), 10),
+		strconv.FormatInt(b.ID, 10), strconv.FormatInt(b.TotalCopies, 10), strconv.FormatInt(b.AvailableCopies, 10),
+	})
+}
+
+func (e *csvBookEncoder) end() error { return nil }
+
+func (e *csvBookEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonBookEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonBookEncoder) begin() error                { return nil }
+func (e *ndjsonBookEncoder) encode(b *domain.Book) error { return e.enc.Encode(b) }
+func (e *ndjsonBookEncoder) end() error                  { return nil }
+func (e *ndjsonBookEncoder) flush() error                { return nil }
+
+// marcBookEncoder writes MARC 21 slim records: 001 control number, 020 ISBN,
+// 100 main author, 245 title, 264 publication year, 520 summary, 650 subject
+// from the category and 856 cover image link.
+type marcBookEncoder struct {
+	w   io.Writer
+	enc *xml.Encoder
+}
+
+type marcRecord struct {
+	XMLName       xml.Name           `xml:"record"`
+	Leader        string             `xml:"leader"`
+	ControlFields []marcControlField `xml:"controlfield"`
+	DataFields    []marcDataField    `xml:"datafield"`
+}
+
+type marcControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type marcDataField struct {
+	Tag       string         `xml:"tag,attr"`
+	Ind1      string         `xml:"ind1,attr"`
+	Ind2      string         `xml:"ind2,attr"`
+	Subfields []marcSubfield `xml:"subfield"`
+}
+
+type marcSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+func (e *marcBookEncoder) begin() error {
+	_, err := io.WriteString(e.w, xml.Header+`<collection xmlns="http://www.loc.gov/MARC21/slim">`+"\n")
+	return err
+}
+
+func (e *marcBookEncoder) encode(b *domain.Book) error {
+	field := func(tag, ind1, ind2 string, subfields ...marcSubfield) marcDataField {
+		return marcDataField{Tag: tag, Ind1: ind1, Ind2: ind2, Subfields: subfields}
+	}
+	rec := marcRecord{
+		Leader:        "     nam a22     4a 4500",
+		ControlFields: []marcControlField{{Tag: "001", Value: strconv.FormatInt(b.ID, 10)}},
+	}
+	if b.ISBN != "" {
+		rec.DataFields = append(rec.DataFields, field("020", " ", " ", marcSubfield{"a", b.ISBN}))
+	}
+	titleInd := "0"
+	if b.Author != "" {
+		rec.DataFields = append(rec.DataFields, field("100", "1", " ", marcSubfield{"a", b.Author}))
+		titleInd = "1"
+	}
+	rec.DataFields = append(rec.DataFields, field("245", titleInd, "0", marcSubfield{"a", b.Title}))
+	if b.Year != 0 {
+		rec.DataFields = append(rec.DataFields, field("264", " ", "1", marcSubfield{"c", strconv.Itoa(b.Year)}))
+	}
+	if b.Summary != "" {
+		rec.DataFields = append(rec.DataFields, field("520", " ", " ", marcSubfield{"a", b.Summary}))
+	}
+	if b.Category.Name != "" {
+		rec.DataFields = append(rec.DataFields, field("650", " ", "4", marcSubfield{"a", b.Category.Name}))
+	}
+	if b.CoverImageURL != "" {
+		rec.DataFields = append(rec.DataFields, field("856", "4", "2",
+			marcSubfield{"3", "Cover image"}, marcSubfield{"u", b.CoverImageURL}))
+	}
+	if err := e.enc.Encode(rec); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n")
+	return err
+}
+
+func (e *marcBookEncoder) end() error {
+	_, err := io.WriteString(e.w, "</collection>\n")
+	return err
+}
+
+func (e *marcBookEncoder) flush() error { return nil }
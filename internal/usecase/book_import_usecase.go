@@ -0,0 +1,327 @@
+package usecase
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/go-playground/validator/v10"
+)
+
+// MaxImportRows caps the size of a single import.
+const MaxImportRows = 10000
+
+type BookImportUseCase struct {
+	books      *BookUseCase
+	categories domain.CategoryRepository
+	validator  *validator.Validate
+}
+
+func NewBookImportUseCase(books *BookUseCase, categories domain.CategoryRepository) *BookImportUseCase {
+	return &BookImportUseCase{
+		books:      books,
+		categories: categories,
+		validator:  validator.New(),
+	}
+}
+
+// importRecord is a parsed record, or the reason it could not be parsed.
+type importRecord struct {
+	line int
+	row  dto.BookImportRow
+	err  error
+}
+
+// importState carries what earlier rows of the same import resolved.
+type importState struct {
+	categories map[string]uint
+	pending    map[string]bool
+	isbns      map[string]int64
+}
+
+// Import creates or updates, by normalized ISBN, one book per record of a csv
+// or ndjson upload. Every record succeeds or fails on its own; in dry-run mode
+// nothing is written and the report tells what would have happened.
+func (uc *BookImportUseCase) Import(r io.Reader, format string, opts dto.BookImportOptions) (*domain.ImportReport, error) {
+	var records []importRecord
+	var err error
+	switch strings.ToLower(format) {
+	case "csv":
+		records, err = parseCSVImport(r)
+	case "ndjson", "jsonl":
+		records, err = parseNDJSONImport(r)
+	default:
+		return nil, domain.ErrImportFormat
+	}
+	if err != nil && !errors.Is(err, domain.ErrValidation) {
+		return nil, fmt.Errorf("%w: %v", domain.ErrMalformedImport, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.ImportReport{DryRun: opts.DryRun, Rows: make([]domain.ImportRowResult, 0, len(records))}
+	state := &importState{
+		categories: map[string]uint{},
+		pending:    map[string]bool{},
+		isbns:      map[string]int64{},
+	}
+	for _, rec := range records {
+		res := uc.importRow(rec, opts, state)
+		switch res.Status {
+		case domain.ImportCreated:
+			report.Created++
+		case domain.ImportUpdated:
+			report.Updated++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, res)
+	}
+	return report, nil
+}
+
+func (uc *BookImportUseCase) importRow(rec importRecord, opts dto.BookImportOptions, state *importState) domain.ImportRowResult {
+	row := rec.row
+	res := domain.ImportRowResult{Line: rec.line, ISBN: row.ISBN, Status: domain.ImportFailed}
+	if rec.err != nil {
+		res.Reason = rec.err.Error()
+		return res
+	}
+
+	if row.ISBN != "" {
+		isbn, err := domain.NormalizeISBN(row.ISBN)
+		if err != nil {
+			res.Reason = failureReason(err)
+			return res
+		}
+		row.ISBN, res.ISBN = isbn, isbn
+	}
+
+	categoryID, pending, err := uc.resolveCategory(row, opts, state)
+	if err != nil {
+		res.Reason = failureReason(err)
+		return res
+	}
+	req := dto.CreateBookRequest{
+		Title:      row.Title,
+		Author:     row.Author,
+		ISBN:       row.ISBN,
+		Year:       row.Year,
+		Summary:    row.Summary,
+		CoverImage: row.CoverImage,
+		CategoryID: categoryID,
+	}
+	// a category that a dry run would create has no id yet
+	if pending {
+		err = uc.validator.StructExcept(req, "CategoryID")
+	} else {
+		err = uc.validator.Struct(req)
+	}
+	if err != nil {
+		res.Reason = failureReason(err)
+		return res
+	}
+
+	existingID, seen := state.isbns[row.ISBN]
+	if !seen {
+		existing, err := uc.books.GetByISBN(row.ISBN)
+		switch {
+		case err == nil:
+			existingID = existing.ID
+		case !errors.Is(err, domain.ErrNotFound):
+			res.Reason = failureReason(err)
+			return res
+		}
+	}
+
+	if opts.DryRun {
+		res.Status, res.BookID = domain.ImportCreated, existingID
+		if existingID != 0 || seen {
+			res.Status = domain.ImportUpdated
+		}
+		state.isbns[row.ISBN] = existingID
+		return res
+	}
+
+	if existingID != 0 {
+		book, err := uc.books.UpdateBook(dto.UpdateBookRequest{
+			ID:         existingID,
+			Title:      req.Title,
+			Author:     req.Author,
+			ISBN:       req.ISBN,
+			Year:       req.Year,
+			Summary:    req.Summary,
+			CoverImage: req.CoverImage,
+			CategoryID: req.CategoryID,
+		})
+		if err != nil {
+			res.Reason = failureReason(err)
+			return res
+		}
+		res.Status, res.BookID = domain.ImportUpdated, book.ID
+		return res
+	}
+	book, err := uc.books.CreateBook(req)
+	if err != nil {
+		res.Reason = failureReason(err)
+		return res
+	}
+	state.isbns[row.ISBN] = book.ID
+	res.Status, res.BookID = domain.ImportCreated, book.ID
+	return res
+}
+
+// resolveCategory returns the category id of a row, looking categories up by
+// name and creating missing ones when allowed. pending reports a category
+// that only a real run would create.
+func (uc *BookImportUseCase) resolveCategory(row dto.BookImportRow, opts dto.BookImportOptions, state *importState) (uint, bool, error) {
+	name := strings.TrimSpace(row.Category)
+	if name == "" {
+		return row.CategoryID, false, nil
+	}
+	key := strings.ToLower(name)
+	if id, ok := state.categories[key]; ok {
+		return id, false, nil
+	}
+	if state.pending[key] {
+		return 0, true, nil
+	}
+
+	category, err := uc.categories.GetByName(name)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return 0, false, err
+	}
+	if category == nil {
+		if !opts.CreateCategories {
+			return 0, false, fmt.Errorf("%w: %q", domain.ErrCategoryNotFound, name)
+		}
+		if opts.DryRun {
+			state.pending[key] = true
+			return 0, true, nil
+		}
+		category = &domain.Category{Name: name}
+		if err := uc.categories.Save(category); err != nil {
+			return 0, false, err
+		}
+	}
+	state.categories[key] = category.ID
+	return category.ID, false, nil
+}
+
+// failureReason describes why a row failed without leaking errors that are
+// not meant for clients, such as those of the database.
+func failureReason(err error) string {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		if _, msg, ok := domain.Describe(err); ok {
+			return msg
+		}
+		return "the row could not be imported"
+	}
+	reasons := make([]string, 0, len(verrs))
+	for _, fe := range verrs {
+		reasons = append(reasons, fmt.Sprintf("%s failed on %s", fe.Field(), fe.Tag()))
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// parseCSVImport reads a csv file whose header names the BookImportRow
+// fields by their json names. Unknown columns are ignored.
+func parseCSVImport(r io.Reader) ([]importRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("csv header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["isbn"]; !ok {
+		return nil, errors.New("csv header: missing isbn column")
+	}
+
+	var records []importRecord
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if len(records) == MaxImportRows {
+			return nil, domain.ErrImportTooLarge
+		}
+		if err != nil {
+			rec := importRecord{err: err}
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				rec.line = perr.StartLine
+			}
+			records = append(records, rec)
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		rec := importRecord{line: line}
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+		rec.row = dto.BookImportRow{
+			Title:      get("title"),
+			Author:     get("author"),
+			ISBN:       get("isbn"),
+			Summary:    get("summary"),
+			CoverImage: get("cover_image"),
+			Category:   get("category"),
+		}
+		if v := get("year"); v != "" {
+			if rec.row.Year, err = strconv.Atoi(v); err != nil {
+				rec.err = fmt.Errorf("year %q is not a number", v)
+			}
+		}
+		if v := get("category_id"); v != "" && rec.err == nil {
+			id, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				rec.err = fmt.Errorf("category_id %q is not a number", v)
+			}
+			rec.row.CategoryID = uint(id)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// parseNDJSONImport reads one json object per line, skipping blank lines.
+func parseNDJSONImport(r io.Reader) ([]importRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var records []importRecord
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if len(records) == MaxImportRows {
+			return nil, domain.ErrImportTooLarge
+		}
+		rec := importRecord{line: line}
+		if err := json.Unmarshal([]byte(text), &rec.row); err != nil {
+			rec.err = fmt.Errorf("invalid json: %w", err)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
@@ -1,24 +1,48 @@
 package usecase
 
 import (
+	"errors"
+	"slices"
+
 	"github.com/abushaista/lms-backend/internal/domain"
 	"github.com/abushaista/lms-backend/internal/dto"
 	"github.com/go-playground/validator/v10"
 )
 
+// AvailabilityListener is called with a book that has a copy back on the shelf.
+type AvailabilityListener func(book *domain.Book) error
+
+// IndexErrorListener is called when the search index could not follow a
+// change of the database. The write itself has already succeeded.
+type IndexErrorListener func(bookID int64, err error)
+
+// reindexBatch is the page size used when rebuilding the search index.
+const reindexBatch = 500
+
 type BookUseCase struct {
-	repo      domain.BookRepository
-	validator *validator.Validate
+	repo          domain.BookRepository
+	index         domain.BookSearchIndex
+	metadata      domain.MetadataProvider
+	validator     *validator.Validate
+	listeners     []AvailabilityListener
+	indexFailures []IndexErrorListener
 }
 
-func NewBookUsecase(r domain.BookRepository) *BookUseCase {
+func NewBookUsecase(r domain.BookRepository, index domain.BookSearchIndex, metadata domain.MetadataProvider) *BookUseCase {
 	return &BookUseCase{
 		repo:      r,
+		index:     index,
+		metadata:  metadata,
 		validator: validator.New(),
 	}
 }
 
 func (uc *BookUseCase) CreateBook(req dto.CreateBookRequest) (*domain.Book, error) {
+	isbn, err := uc.checkISBN(req.ISBN, 0)
+	if err != nil {
+		return nil, err
+	}
+	req.ISBN = isbn
 	if err := uc.validator.Struct(req); err != nil {
 		return nil, err
 	}
@@ -30,7 +54,8 @@ func (uc *BookUseCase) CreateBook(req dto.CreateBookRequest) (*domain.Book, erro
 		Summary:       req.Summary,
 		CoverImageURL: req.CoverImage,
 		CategoryID:    req.CategoryID,
-		Available:     req.Available,
+		Authors:       bookAuthors(req.AuthorIDs, req.Author),
+		Tags:          bookTags(req.TagIDs),
 	}
 	id, err := uc.repo.Save(&book)
 	// Save to DB
@@ -38,13 +63,28 @@ func (uc *BookUseCase) CreateBook(req dto.CreateBookRequest) (*domain.Book, erro
 		return nil, err
 	}
 	book.ID = id
+	uc.syncIndex(id)
 	return &book, nil
 }
 
 func (uc *BookUseCase) UpdateBook(req dto.UpdateBookRequest) (*domain.Book, error) {
+	isbn, err := uc.checkISBN(req.ISBN, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	req.ISBN = isbn
 	if err := uc.validator.Struct(req); err != nil {
 		return nil, err
 	}
+	existing, err := uc.repo.GetByID(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	// a book with an uploaded cover keeps it unless another URL replaces it;
+	// the file stays stored until the next upload
+	if req.CoverImage == "" && existing.CoverImageKey != "" {
+		req.CoverImage = existing.CoverImageURL
+	}
 	book := domain.Book{
 		ID:            req.ID,
 		Title:         req.Title,
@@ -53,28 +93,280 @@ func (uc *BookUseCase) UpdateBook(req dto.UpdateBookRequest) (*domain.Book, erro
 		Year:          req.Year,
 		Summary:       req.Summary,
 		CoverImageURL: req.CoverImage,
+		CoverImageKey: existing.CoverImageKey,
 		CategoryID:    req.CategoryID,
+		Authors:       bookAuthors(req.AuthorIDs, req.Author),
+		Tags:          bookTags(req.TagIDs),
+		Version:       req.Version,
+		CreatedAt:     existing.CreatedAt,
+
+		Available:       existing.Available,
+		TotalCopies:     existing.TotalCopies,
+		AvailableCopies: existing.AvailableCopies,
 	}
-	_, err := uc.repo.Save(&book)
+	if book.Tags == nil {
+		book.Tags = existing.Tags
+	}
+	_, err = uc.repo.Save(&book)
 
 	if err != nil {
 		return nil, err
 	}
+	uc.syncIndex(book.ID)
 	return &book, nil
 }
 
-func (uc *BookUseCase) GetByFilterAll(page, limit int, filter domain.BookFilter) ([]*domain.Book, int64, error) {
-	data, total, err := uc.repo.GetAll(page, limit, filter)
+// PatchBook lets patch edit the update request describing the current state
+// of a book, then saves the result like UpdateBook, which validates it. The
+// book must still have version, unless version is 0.
+func (uc *BookUseCase) PatchBook(id, version int64, patch func(req *dto.UpdateBookRequest) error) (*domain.Book, error) {
+	book, err := uc.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && book.Version != version {
+		return nil, domain.ErrVersionMismatch
+	}
+	req := dto.UpdateBookRequest{
+		ID:         book.ID,
+		Title:      book.Title,
+		Author:     book.Author,
+		ISBN:       book.ISBN,
+		Year:       book.Year,
+		Summary:    book.Summary,
+		CoverImage: book.CoverImageURL,
+		CategoryID: book.CategoryID,
+		AuthorIDs:  make([]uint, len(book.Authors)),
+		TagIDs:     make([]uint, len(book.Tags)),
+	}
+	for i, a := range book.Authors {
+		req.AuthorIDs[i] = a.ID
+	}
+	for i, t := range book.Tags {
+		req.TagIDs[i] = t.ID
+	}
+	authorIDs := slices.Clone(req.AuthorIDs)
+	if err := patch(&req); err != nil {
+		return nil, err
+	}
+	// a new byline without new author ids names the authors itself
+	if req.Author != book.Author && slices.Equal(req.AuthorIDs, authorIDs) {
+		req.AuthorIDs = nil
+	}
+	// the patch applies to the book as read, whatever it says about these
+	req.ID, req.Version = id, book.Version
+	return uc.UpdateBook(req)
+}
+
+// bookAuthors lists the authors to link to a book: the given ids, or else
+// the names found in its byline.
+func bookAuthors(ids []uint, byline string) []domain.Author {
+	if len(ids) > 0 {
+		authors := make([]domain.Author, len(ids))
+		for i, id := range ids {
+			authors[i].ID = id
+		}
+		return authors
+	}
+	names := domain.SplitAuthors(byline)
+	authors := make([]domain.Author, len(names))
+	for i, name := range names {
+		authors[i].Name = name
+	}
+	return authors
+}
+
+// bookTags lists the tags to link to a book; nil keeps the current ones.
+func bookTags(ids []uint) []domain.Tag {
+	if ids == nil {
+		return nil
+	}
+	tags := make([]domain.Tag, len(ids))
+	for i, id := range ids {
+		tags[i].ID = id
+	}
+	return tags
+}
+
+func (uc *BookUseCase) GetByFilterAll(filter domain.BookFilter, q domain.PageQuery) (*domain.Page[*domain.Book], error) {
+	return uc.repo.GetAll(filter, q)
+}
+
+// DeleteBook deletes a book that still has version, or any version when
+// version is 0.
+func (uc *BookUseCase) DeleteBook(id, version int64) error {
+	if err := uc.repo.Delete(id, version); err != nil {
+		return err
+	}
+	if err := uc.index.Remove(id); err != nil {
+		uc.indexFailed(id, err)
+	}
+	return nil
+}
+
+// Search runs a full-text query against the search index and loads the
+// matching books from the database. Hits of books deleted in the meantime
+// are skipped.
+func (uc *BookUseCase) Search(q domain.BookSearchQuery) (*domain.BookSearchResult, error) {
+	result, err := uc.index.Search(q)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+	hits := make([]domain.BookSearchHit, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		book, err := uc.repo.GetByID(hit.BookID)
+		if errors.Is(err, domain.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		hit.Book = book
+		hits = append(hits, hit)
 	}
-	return data, total, nil
+	result.Hits = hits
+	return result, nil
+}
+
+// Reindex pushes every book of the database into the search index.
+func (uc *BookUseCase) Reindex() error {
+	return uc.repo.Stream(domain.BookFilter{}, reindexBatch, uc.index.Index)
 }
 
-func (uc *BookUseCase) DeleteBook(id int64) error {
-	return uc.repo.Delete(id)
+// OnIndexError registers a listener for search index updates that failed.
+func (uc *BookUseCase) OnIndexError(l IndexErrorListener) {
+	uc.indexFailures = append(uc.indexFailures, l)
+}
+
+// syncIndex reloads the saved book, so the document carries its category
+// name, and writes it to the search index, or removes it from the index when
+// the book is gone.
+func (uc *BookUseCase) syncIndex(id int64) {
+	book, err := uc.repo.GetByID(id)
+	if err == nil {
+		err = uc.index.Index(book)
+	} else if errors.Is(err, domain.ErrNotFound) {
+		err = uc.index.Remove(id)
+	}
+	if err != nil {
+		uc.indexFailed(id, err)
+	}
+}
+
+// reindexCategory rewrites the documents of the books in a category, whose
+// category name is copied into them.
+func (uc *BookUseCase) reindexCategory(id uint) {
+	err := uc.repo.Stream(domain.BookFilter{Categories: []uint{id}}, reindexBatch, func(b *domain.Book) error {
+		if err := uc.index.Index(b); err != nil {
+			uc.indexFailed(b.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		// no book to blame, the listing itself failed
+		uc.indexFailed(0, err)
+	}
+}
+
+func (uc *BookUseCase) indexFailed(id int64, err error) {
+	for _, l := range uc.indexFailures {
+		l(id, err)
+	}
 }
 
 func (uc *BookUseCase) GetByID(id int64) (*domain.Book, error) {
 	return uc.repo.GetByID(id)
 }
+
+// Lookup fetches the bibliographic data of an ISBN from the metadata provider.
+func (uc *BookUseCase) Lookup(isbn string) (*domain.BookMetadata, error) {
+	normalized, err := domain.NormalizeISBN(isbn)
+	if err != nil {
+		return nil, err
+	}
+	return uc.metadata.Lookup(normalized)
+}
+
+// Enrich fills the empty fields of req from the metadata of its ISBN. An ISBN
+// the provider does not know leaves req as it is.
+func (uc *BookUseCase) Enrich(req *dto.CreateBookRequest) error {
+	meta, err := uc.Lookup(req.ISBN)
+	if errors.Is(err, domain.ErrMetadataNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if req.Title == "" {
+		req.Title = meta.Title
+	}
+	if req.Author == "" {
+		req.Author = meta.Author
+	}
+	if req.Year == 0 {
+		req.Year = meta.Year
+	}
+	if req.Summary == "" {
+		req.Summary = meta.Summary
+	}
+	if req.CoverImage == "" {
+		req.CoverImage = meta.CoverImageURL
+	}
+	return nil
+}
+
+// GetByISBN looks a book up by any ISBN-10 or ISBN-13 spelling.
+func (uc *BookUseCase) GetByISBN(isbn string) (*domain.Book, error) {
+	normalized, err := domain.NormalizeISBN(isbn)
+	if err != nil {
+		return nil, err
+	}
+	return uc.repo.GetByISBN(normalized)
+}
+
+// checkISBN normalizes isbn to ISBN-13 and makes sure no book other than
+// bookID already has it.
+func (uc *BookUseCase) checkISBN(isbn string, bookID int64) (string, error) {
+	normalized, err := domain.NormalizeISBN(isbn)
+	if err != nil {
+		return "", err
+	}
+	existing, err := uc.repo.GetByISBN(normalized)
+	if errors.Is(err, domain.ErrNotFound) {
+		return normalized, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if existing.ID != bookID {
+		return "", &domain.DuplicateISBNError{ISBN: normalized, BookID: existing.ID}
+	}
+	return normalized, nil
+}
+
+// OnAvailable registers a listener for books flipping back to available.
+func (uc *BookUseCase) OnAvailable(l AvailabilityListener) {
+	uc.listeners = append(uc.listeners, l)
+}
+
+// RefreshAvailability reloads the book after one of its copies was released
+// and notifies the listeners when the book is available again. A book deleted
+// meanwhile has nobody to notify.
+func (uc *BookUseCase) RefreshAvailability(id int64) error {
+	book, err := uc.repo.GetByID(id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !book.Available {
+		return nil
+	}
+	for _, l := range uc.listeners {
+		if err := l(book); err != nil {
+			return err
+		}
+	}
+	return nil
+}
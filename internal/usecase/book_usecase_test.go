@@ -0,0 +1,166 @@
+package usecase_test
+
+import (
+	"bytes"
+	"image"
+	imagepng "image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/abushaista/lms-backend/internal/repository"
+	"github.com/abushaista/lms-backend/internal/usecase"
+)
+
+func TestBookUseCaseEnrich(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("bibkeys") != "ISBN:9780306406157" {
+			io.WriteString(w, `{}`)
+			return
+		}
+		io.WriteString(w, `{"ISBN:9780306406157": {
+			"title": "Dune",
+			"publish_date": "1965",
+			"authors": [{"name": "Frank Herbert"}],
+			"notes": "A desert planet.",
+			"cover": {"large": "https://covers.example/l.jpg"}
+		}}`)
+	}))
+	defer srv.Close()
+	uc := usecase.NewBookUsecase(nil, nil, repository.NewOpenLibraryProvider(srv.URL, nil))
+
+	// the ISBN-10 spelling is looked up as ISBN-13
+	req := dto.CreateBookRequest{ISBN: "0-306-40615-2", Title: "Dune (Ace edition)", Year: 1990}
+	if err := uc.Enrich(&req); err != nil {
+		t.Fatal(err)
+	}
+	want := dto.CreateBookRequest{
+		ISBN:       "0-306-40615-2",
+		Title:      "Dune (Ace edition)",
+		Author:     "Frank Herbert",
+		Year:       1990,
+		Summary:    "A desert planet.",
+		CoverImage: "https://covers.example/l.jpg",
+	}
+	if req.Title != want.Title || req.Author != want.Author || req.Year != want.Year ||
+		req.Summary != want.Summary || req.CoverImage != want.CoverImage || req.ISBN != want.ISBN {
+		t.Errorf("got %+v, want %+v", req, want)
+	}
+
+	unknown := dto.CreateBookRequest{ISBN: "9780198526636", Title: "Foundation"}
+	if err := uc.Enrich(&unknown); err != nil {
+		t.Errorf("enriching an unknown ISBN: %v", err)
+	}
+	if unknown.Title != "Foundation" || unknown.Author != "" || unknown.Summary != "" {
+		t.Errorf("an unknown ISBN changed the request: %+v", unknown)
+	}
+}
+
+func TestBookUseCasePatchBookAuthors(t *testing.T) {
+	s := repository.NewMemoryStore()
+	if err := repository.NewMemoryCategoryRepository(s).Save(&domain.Category{Name: "Fiction"}); err != nil {
+		t.Fatal(err)
+	}
+	uc := usecase.NewBookUsecase(repository.NewMemoryBookRepository(s), repository.NewMemoryBookIndex(), nil)
+	book, err := uc.CreateBook(dto.CreateBookRequest{Title: "Good Omens", Author: "Terry Pratchett",
+		ISBN: "9780306406157", Year: 1990, Summary: "the end of the world", CategoryID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := func(b *domain.Book) []string {
+		var names []string
+		for _, a := range b.Authors {
+			names = append(names, a.Name)
+		}
+		return names
+	}
+
+	// a new byline names the authors
+	book, err = uc.PatchBook(book.ID, 0, func(req *dto.UpdateBookRequest) error {
+		req.Author = "Terry Pratchett & Neil Gaiman"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(book); !slices.Equal(got, []string{"Terry Pratchett", "Neil Gaiman"}) {
+		t.Errorf("got authors %q after changing the byline", got)
+	}
+
+	// author ids win over the byline
+	gaiman := book.Authors[1].ID
+	book, err = uc.PatchBook(book.ID, 0, func(req *dto.UpdateBookRequest) error {
+		req.Author = "Neil Gaiman (ed.)"
+		req.AuthorIDs = []uint{gaiman}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(book); !slices.Equal(got, []string{"Neil Gaiman"}) || book.Author != "Neil Gaiman (ed.)" {
+		t.Errorf("got authors %q and byline %q after setting author ids", got, book.Author)
+	}
+
+	// other changes keep the authors
+	book, err = uc.PatchBook(book.ID, 0, func(req *dto.UpdateBookRequest) error {
+		req.Year = 2006
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(book); !slices.Equal(got, []string{"Neil Gaiman"}) {
+		t.Errorf("got authors %q after changing the year", got)
+	}
+}
+
+func TestBookUseCasePatchBookUploadedCover(t *testing.T) {
+	s := repository.NewMemoryStore()
+	if err := repository.NewMemoryCategoryRepository(s).Save(&domain.Category{Name: "Fiction"}); err != nil {
+		t.Fatal(err)
+	}
+	repo := repository.NewMemoryBookRepository(s)
+	uc := usecase.NewBookUsecase(repo, repository.NewMemoryBookIndex(), nil)
+	covers := usecase.NewBookCoverUseCase(repo, repository.NewLocalCoverStorage(t.TempDir()), 1<<20)
+	book, err := uc.CreateBook(dto.CreateBookRequest{Title: "Dune", Author: "Frank Herbert",
+		ISBN: "9780306406157", Year: 1965, Summary: "a desert planet", CategoryID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var png bytes.Buffer
+	if err := imagepng.Encode(&png, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	uploaded, err := covers.Upload(book.ID, &png)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	book, err = uc.PatchBook(book.ID, 0, func(req *dto.UpdateBookRequest) error {
+		req.Year = 1966
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("patching a book with an uploaded cover: %v", err)
+	}
+	if book.CoverImageURL != uploaded.CoverImageURL {
+		t.Errorf("got cover %q after a patch, want %q", book.CoverImageURL, uploaded.CoverImageURL)
+	}
+
+	// an update leaving the cover out keeps the uploaded one
+	book, err = uc.UpdateBook(dto.UpdateBookRequest{ID: book.ID, Title: "Dune", Author: "Frank Herbert",
+		ISBN: "9780306406157", Year: 1965, Summary: "a desert planet", CategoryID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.CoverImageURL != uploaded.CoverImageURL || book.CoverImageKey == "" {
+		t.Errorf("got cover %q stored under %q after an update without one", book.CoverImageURL, book.CoverImageKey)
+	}
+	if _, _, err := covers.Open(book.ID, false); err != nil {
+		t.Errorf("opening the uploaded cover: %v", err)
+	}
+}
@@ -1,19 +1,25 @@
 package usecase
 
 import (
+	"cmp"
+	"slices"
+
 	"github.com/abushaista/lms-backend/internal/domain"
 	"github.com/abushaista/lms-backend/internal/dto"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 type CategoryUseCase struct {
 	repo      domain.CategoryRepository
+	books     *BookUseCase
 	validator *validator.Validate
 }
 
-func NewCategoryUseCase(r domain.CategoryRepository) *CategoryUseCase {
+func NewCategoryUseCase(r domain.CategoryRepository, books *BookUseCase) *CategoryUseCase {
 	return &CategoryUseCase{
 		repo:      r,
+		books:     books,
 		validator: validator.New(),
 	}
 }
@@ -22,31 +28,174 @@ func (uc *CategoryUseCase) Save(req dto.CategoryRequest) (*domain.Category, erro
 	if err := uc.validator.Struct(req); err != nil {
 		return nil, err
 	}
+	var renamed bool
+	if req.ID != 0 {
+		current, err := uc.repo.GetByID(req.ID)
+		if err != nil {
+			return nil, err
+		}
+		renamed = current.Name != req.Name
+	}
 
 	category := domain.Category{
-		ID:   req.ID,
-		Name: req.Name,
+		ID:       req.ID,
+		Name:     req.Name,
+		ParentID: req.ParentID,
+		Version:  req.Version,
 	}
 	if err := uc.repo.Save(&category); err != nil {
 		return nil, err
 	}
+	// the search documents carry the category name for the facets
+	if renamed {
+		uc.books.reindexCategory(category.ID)
+	}
 	return &category, nil
 }
 
-func (uc *CategoryUseCase) GetByID(id uint) (*domain.Category, error) {
+// Patch lets patch edit the request describing the current state of a
+// category, then saves the result like Save, which validates it. The
+// category must still have version, unless version is 0.
+func (uc *CategoryUseCase) Patch(id uint, version int64, patch func(req *dto.CategoryRequest) error) (*domain.Category, error) {
+	category, err := uc.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && category.Version != version {
+		return nil, domain.ErrVersionMismatch
+	}
+	req := dto.CategoryRequest{
+		ID:       category.ID,
+		Name:     category.Name,
+		ParentID: category.ParentID,
+	}
+	if err := patch(&req); err != nil {
+		return nil, err
+	}
+	req.ID, req.Version = id, category.Version
+	return uc.Save(req)
+}
+
+// Move places a category under parentID, or at the top level when parentID
+// is nil, keeping its own subtree attached.
+func (uc *CategoryUseCase) Move(id uint, parentID *uint) (*domain.Category, error) {
+	if err := uc.repo.SetParent(id, parentID); err != nil {
+		return nil, err
+	}
 	return uc.repo.GetByID(id)
 }
 
-func (uc *CategoryUseCase) GetByFilterAll(page, limit int, filter string) ([]*domain.Category, int64, error) {
-	data, total, err := uc.repo.GetByFilterAll(page, limit, filter)
+// Tree returns the top-level categories with their descendants nested in
+// Children.
+func (uc *CategoryUseCase) Tree() ([]*domain.Category, error) {
+	categories, err := uc.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	byID := linkCategories(categories)
+	roots := []*domain.Category{}
+	for _, c := range categories {
+		// children of a deleted category surface at the top
+		if c.ParentID == nil || byID[*c.ParentID] == nil {
+			roots = append(roots, c)
+		}
+	}
+	return roots, nil
+}
+
+// Subtree returns a category with its descendants nested in Children.
+func (uc *CategoryUseCase) Subtree(id uint) (*domain.Category, error) {
+	categories, err := uc.repo.GetAll()
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
-	return data, total, nil
+	root := linkCategories(categories)[id]
+	if root == nil {
+		return nil, domain.ErrCategoryNotFound
+	}
+	return root, nil
 }
 
-func (uc *CategoryUseCase) Delete(id uint) error {
-	return uc.repo.Delete(id)
+// linkCategories fills the Children of every category, ordered by name, and
+// indexes them by id.
+func linkCategories(categories []*domain.Category) map[uint]*domain.Category {
+	slices.SortFunc(categories, func(a, b *domain.Category) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	byID := make(map[uint]*domain.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+	for _, c := range categories {
+		if c.ParentID == nil {
+			continue
+		}
+		if parent := byID[*c.ParentID]; parent != nil {
+			parent.Children = append(parent.Children, c)
+		}
+	}
+	return byID
+}
+
+// Merge folds the source categories into target: their books and
+// subcategories move to target and they are deleted. Every source gets an
+// audit record naming the user who merged it.
+func (uc *CategoryUseCase) Merge(target uint, req dto.MergeCategoriesRequest, mergedBy uuid.UUID) ([]*domain.CategoryMerge, error) {
+	if err := uc.validator.Struct(req); err != nil {
+		return nil, err
+	}
+	var merges []*domain.CategoryMerge
+	seen := map[uint]bool{}
+	for _, source := range req.SourceIDs {
+		if source == target {
+			return nil, domain.ErrInvalidMerge
+		}
+		if seen[source] {
+			continue
+		}
+		seen[source] = true
+		merges = append(merges, &domain.CategoryMerge{
+			TargetID: target,
+			SourceID: source,
+			MergedBy: mergedBy,
+			Note:     req.Note,
+		})
+	}
+
+	bookIDs, err := uc.repo.Merge(merges)
+	if err != nil {
+		return nil, err
+	}
+	for _, bookID := range bookIDs {
+		uc.books.syncIndex(bookID)
+	}
+	return merges, nil
+}
+
+// GetMerges lists the merges into a category, newest first.
+func (uc *CategoryUseCase) GetMerges(target uint) ([]*domain.CategoryMerge, error) {
+	return uc.repo.GetMerges(target)
+}
+
+func (uc *CategoryUseCase) GetByID(id uint) (*domain.Category, error) {
+	return uc.repo.GetByID(id)
+}
+
+func (uc *CategoryUseCase) GetByFilterAll(filter string, q domain.PageQuery) (*domain.Page[*domain.Category], error) {
+	return uc.repo.GetByFilterAll(filter, q)
+}
+
+// Delete removes a category whose books are reassigned or deleted as d says,
+// and updates the search index of those books.
+func (uc *CategoryUseCase) Delete(id uint, d domain.CategoryDeletion) error {
+	bookIDs, err := uc.repo.Delete(id, d)
+	if err != nil {
+		return err
+	}
+	for _, bookID := range bookIDs {
+		uc.books.syncIndex(bookID)
+	}
+	return nil
 }
 
 func (uc *CategoryUseCase) GetAll() ([]*domain.Category, error) {
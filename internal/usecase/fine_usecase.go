@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type FineUseCase struct {
+	repo      domain.FineRepository
+	policy    domain.FinePolicy
+	validator *validator.Validate
+}
+
+func NewFineUseCase(r domain.FineRepository, policy domain.FinePolicy) *FineUseCase {
+	return &FineUseCase{
+		repo:      r,
+		policy:    policy,
+		validator: validator.New(),
+	}
+}
+
+// Assess returns the late fee of a returned loan, to be charged along with the
+// return. It returns nil when the loan came back in time.
+func (uc *FineUseCase) Assess(loan *domain.Loan) *domain.Fine {
+	if loan.ReturnedAt == nil {
+		return nil
+	}
+	amount := uc.policy.Assess(loan.DueAt, *loan.ReturnedAt)
+	if amount == 0 {
+		return nil
+	}
+	return &domain.Fine{
+		UserID:      loan.UserID,
+		LoanID:      loan.ID,
+		Amount:      amount,
+		Reason:      fmt.Sprintf("late return of book %d", loan.BookID),
+		Outstanding: amount,
+	}
+}
+
+// CheckBorrowing returns ErrFineBalanceExceeded when the outstanding fines of
+// the user, together with the fees accruing on the overdue loans still out,
+// are above the borrowing threshold.
+func (uc *FineUseCase) CheckBorrowing(userID uuid.UUID, overdue []*domain.Loan) error {
+	balance, err := uc.repo.OutstandingBalance(userID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, l := range overdue {
+		balance += uc.policy.Assess(l.DueAt, now)
+	}
+	if balance > uc.policy.BlockThreshold {
+		return domain.ErrFineBalanceExceeded
+	}
+	return nil
+}
+
+// GetByUser returns the fine ledger of the user and the outstanding balance.
+func (uc *FineUseCase) GetByUser(userID uuid.UUID) ([]*domain.Fine, int64, error) {
+	fines, err := uc.repo.GetByUser(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	var balance int64
+	for _, f := range fines {
+		balance += f.Outstanding
+	}
+	return fines, balance, nil
+}
+
+// Pay records a payment taken by staff; it is credited to the member owing
+// the fine.
+func (uc *FineUseCase) Pay(id int64, req dto.FinePaymentRequest) (*domain.Fine, error) {
+	if err := uc.validator.Struct(req); err != nil {
+		return nil, err
+	}
+	fine, err := uc.getOpenFine(id)
+	if err != nil {
+		return nil, err
+	}
+	return uc.repo.Apply(&domain.FineTransaction{
+		FineID: fine.ID,
+		UserID: fine.UserID,
+		Kind:   domain.FinePayment,
+		Amount: req.Amount,
+		Note:   req.Note,
+	})
+}
+
+func (uc *FineUseCase) Waive(id int64, req dto.FineWaiverRequest) (*domain.Fine, error) {
+	if err := uc.validator.Struct(req); err != nil {
+		return nil, err
+	}
+	fine, err := uc.getOpenFine(id)
+	if err != nil {
+		return nil, err
+	}
+	amount := req.Amount
+	if amount == 0 {
+		amount = fine.Outstanding
+	}
+	return uc.repo.Apply(&domain.FineTransaction{
+		FineID: fine.ID,
+		UserID: fine.UserID,
+		Kind:   domain.FineWaiver,
+		Amount: amount,
+		Note:   req.Note,
+	})
+}
+
+func (uc *FineUseCase) getOpenFine(id int64) (*domain.Fine, error) {
+	fine, err := uc.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if fine.Outstanding == 0 {
+		return nil, domain.ErrFineSettled
+	}
+	return fine, nil
+}
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+const (
+	LoanPeriod  = 14 * 24 * time.Hour
+	MaxRenewals = 2
+)
+
+type LoanUseCase struct {
+	repo      domain.LoanRepository
+	fines     *FineUseCase
+	validator *validator.Validate
+}
+
+func NewLoanUseCase(r domain.LoanRepository, fines *FineUseCase) *LoanUseCase {
+	return &LoanUseCase{
+		repo:      r,
+		fines:     fines,
+		validator: validator.New(),
+	}
+}
+
+func (uc *LoanUseCase) Checkout(userID uuid.UUID, req dto.CheckoutRequest) (*domain.Loan, error) {
+	if err := uc.validator.Struct(req); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	overdue, err := uc.repo.GetOverdueByUser(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.fines.CheckBorrowing(userID, overdue); err != nil {
+		return nil, err
+	}
+	loan := domain.Loan{
+		UserID:     userID,
+		BookID:     req.BookID,
+		BorrowedAt: now,
+		DueAt:      now.Add(LoanPeriod),
+	}
+	if err := uc.repo.Checkout(&loan); err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+// Return checks in the copy of a loan handed back at the desk, whoever
+// borrowed it.
+func (uc *LoanUseCase) Return(id int64) (*domain.Loan, error) {
+	loan, err := uc.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !loan.IsOpen() {
+		return nil, domain.ErrLoanReturned
+	}
+	now := time.Now()
+	loan.ReturnedAt = &now
+	if err := uc.repo.Return(loan, uc.fines.Assess(loan)); err != nil {
+		return nil, err
+	}
+	return loan, nil
+}
+
+func (uc *LoanUseCase) Renew(userID uuid.UUID, id int64) (*domain.Loan, error) {
+	loan, err := uc.ownedLoan(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !loan.IsOpen() {
+		return nil, domain.ErrLoanReturned
+	}
+	now := time.Now()
+	if loan.IsOverdue(now) {
+		return nil, domain.ErrLoanOverdue
+	}
+	if loan.Renewals >= MaxRenewals {
+		return nil, domain.ErrRenewLimitReached
+	}
+	if err := uc.repo.Renew(loan, loan.DueAt.Add(LoanPeriod)); err != nil {
+		return nil, err
+	}
+	return loan, nil
+}
+
+func (uc *LoanUseCase) GetByUser(userID uuid.UUID, page, limit int) ([]*domain.Loan, int64, error) {
+	data, total, err := uc.repo.GetByUser(userID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
+
+func (uc *LoanUseCase) GetOverdue(page, limit int) ([]*domain.Loan, int64, error) {
+	data, total, err := uc.repo.GetOverdue(time.Now(), page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
+
+func (uc *LoanUseCase) ownedLoan(userID uuid.UUID, id int64) (*domain.Loan, error) {
+	loan, err := uc.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if loan.UserID != userID {
+		return nil, domain.ErrLoanNotOwned
+	}
+	return loan, nil
+}
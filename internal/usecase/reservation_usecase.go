@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type ReservationUseCase struct {
+	repo      domain.ReservationRepository
+	books     *BookUseCase
+	validator *validator.Validate
+}
+
+// NewReservationUseCase subscribes the hold queue to the book availability so
+// the next hold is promoted when staff shelve a copy. Returned copies and
+// copies of closed holds are handed on by the repositories themselves.
+func NewReservationUseCase(r domain.ReservationRepository, books *BookUseCase) *ReservationUseCase {
+	uc := &ReservationUseCase{
+		repo:      r,
+		books:     books,
+		validator: validator.New(),
+	}
+	books.OnAvailable(uc.promote)
+	return uc
+}
+
+func (uc *ReservationUseCase) Place(userID uuid.UUID, req dto.ReservationRequest) (*domain.Reservation, error) {
+	if err := uc.validator.Struct(req); err != nil {
+		return nil, err
+	}
+	reservation := domain.Reservation{
+		UserID: userID,
+		BookID: req.BookID,
+		Status: domain.ReservationWaiting,
+	}
+	if err := uc.repo.Place(&reservation); err != nil {
+		return nil, err
+	}
+	if err := uc.withPosition(&reservation); err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Cancel withdraws a hold of the user. A copy set aside for it goes to the next hold.
+func (uc *ReservationUseCase) Cancel(userID uuid.UUID, id int64) (*domain.Reservation, error) {
+	reservation, err := uc.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.UserID != userID {
+		return nil, domain.ErrReservationNotOwned
+	}
+	if !reservation.IsActive() {
+		return nil, domain.ErrReservationClosed
+	}
+	if err := uc.repo.Close(reservation, domain.ReservationCancelled); err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+func (uc *ReservationUseCase) GetByUser(userID uuid.UUID) ([]*domain.Reservation, error) {
+	reservations, err := uc.repo.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range reservations {
+		if err := uc.withPosition(r); err != nil {
+			return nil, err
+		}
+	}
+	return reservations, nil
+}
+
+// GetQueue returns the active holds of a book in pickup order.
+func (uc *ReservationUseCase) GetQueue(bookID int64) ([]*domain.Reservation, error) {
+	if _, err := uc.books.GetByID(bookID); err != nil {
+		return nil, err
+	}
+	reservations, err := uc.repo.GetQueue(bookID)
+	if err != nil {
+		return nil, err
+	}
+	var position int64
+	for _, r := range reservations {
+		if r.Status == domain.ReservationWaiting {
+			position++
+			r.Position = position
+		}
+	}
+	return reservations, nil
+}
+
+// ExpireReady closes the ready holds that were not picked up in time.
+func (uc *ReservationUseCase) ExpireReady() error {
+	expired, err := uc.repo.GetExpired(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, r := range expired {
+		if err := uc.repo.Close(r, domain.ReservationExpired); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// promote hands the available copies of the book to the head of its queue.
+func (uc *ReservationUseCase) promote(book *domain.Book) error {
+	for {
+		now := time.Now()
+		promoted, err := uc.repo.PromoteNext(book.ID, now, now.Add(domain.HoldPickupWindow))
+		if err != nil {
+			return err
+		}
+		if promoted == nil {
+			return nil
+		}
+	}
+}
+
+// withPosition sets the 1-based queue position of a waiting hold.
+func (uc *ReservationUseCase) withPosition(r *domain.Reservation) error {
+	if r.Status != domain.ReservationWaiting {
+		return nil
+	}
+	ahead, err := uc.repo.CountAhead(r)
+	if err != nil {
+		return err
+	}
+	r.Position = ahead + 1
+	return nil
+}
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"strings"
+
+	"github.com/abushaista/lms-backend/internal/domain"
+	"github.com/abushaista/lms-backend/internal/dto"
+	"github.com/go-playground/validator/v10"
+)
+
+type TagUseCase struct {
+	repo      domain.TagRepository
+	validator *validator.Validate
+}
+
+func NewTagUseCase(r domain.TagRepository) *TagUseCase {
+	return &TagUseCase{
+		repo:      r,
+		validator: validator.New(),
+	}
+}
+
+func (uc *TagUseCase) Save(req dto.TagRequest) (*domain.Tag, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := uc.validator.Struct(req); err != nil {
+		return nil, err
+	}
+
+	tag := &domain.Tag{Name: req.Name}
+	if req.ID != 0 {
+		existing, err := uc.repo.GetByID(req.ID)
+		if err != nil {
+			return nil, err
+		}
+		existing.Name = req.Name
+		tag = existing
+	}
+	if err := uc.repo.Save(tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (uc *TagUseCase) GetByID(id uint) (*domain.Tag, error) {
+	return uc.repo.GetByID(id)
+}
+
+func (uc *TagUseCase) GetByFilterAll(page, limit int, filter string) ([]*domain.Tag, int64, error) {
+	return uc.repo.GetByFilterAll(page, limit, filter)
+}
+
+func (uc *TagUseCase) Delete(id uint) error {
+	return uc.repo.Delete(id)
+}